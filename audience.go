@@ -0,0 +1,35 @@
+package sos
+
+// Audience indicates who an error is being rendered for.
+//
+// Audiences are ordered from least to most privileged so that the zero value,
+// AudiencePublic, is always the safest choice.
+type Audience int
+
+// Supported audiences.
+const (
+	// AudiencePublic is an untrusted client such as an end user.
+	AudiencePublic Audience = iota
+	// AudiencePartner is a trusted, but external, client.
+	AudiencePartner
+	// AudienceInternal is the service itself (i.e., logs, traces, etc.).
+	AudienceInternal
+)
+
+// String implements the fmt.Stringer interface.
+func (a Audience) String() string {
+	switch a {
+	case AudiencePublic:
+		return "public"
+	case AudiencePartner:
+		return "partner"
+	case AudienceInternal:
+		return "internal"
+	}
+	return "unknown"
+}
+
+// allows reports whether the audience is privileged enough to see a value with visibility v.
+func (a Audience) allows(v Audience) bool {
+	return a >= v
+}
@@ -0,0 +1,144 @@
+package sos_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bjaus/sos"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAudience(t *testing.T) {
+
+	leak := "dial tcp db.internal:5432: connection refused"
+
+	err := sos.New(sos.TEMPORARY).
+		WithError(errors.New(leak)).
+		WithPublicMessage("please try again later").
+		WithDetail("host", "db.internal").
+		WithPartnerDetail("region", "us-east-1").
+		WithPublicDetail("retry", "30s")
+
+	type testcase struct {
+		message string
+		details map[string]string
+	}
+
+	cases := map[sos.Audience]testcase{
+		sos.AudiencePublic: {
+			message: "please try again later",
+			details: map[string]string{"retry": "30s"},
+		},
+		sos.AudiencePartner: {
+			message: "please try again later",
+			details: map[string]string{"retry": "30s", "region": "us-east-1"},
+		},
+		sos.AudienceInternal: {
+			message: leak,
+			details: map[string]string{"retry": "30s", "region": "us-east-1", "host": "db.internal"},
+		},
+	}
+
+	for aud, tc := range cases {
+		t.Run(aud.String(), func(t *testing.T) {
+			if got := err.MessageFor(aud); got != tc.message {
+				t.Errorf("message: got %q, want %q", got, tc.message)
+			}
+			if diff := cmp.Diff(err.DetailsFor(aud), tc.details); diff != "" {
+				t.Error(diff)
+			}
+
+			b, e := json.Marshal(err.Problem(aud))
+			if e != nil {
+				t.Fatal(e)
+			}
+			if aud != sos.AudienceInternal {
+				if strings.Contains(string(b), "db.internal") {
+					t.Errorf("problem leaked internal information: %s", b)
+				}
+				if strings.Contains(err.TraceFor(aud), ".go:") {
+					t.Errorf("trace leaked frames: %s", err.TraceFor(aud))
+				}
+			}
+		})
+	}
+
+	t.Run("detail visibility reset", func(t *testing.T) {
+		err := sos.New(sos.INVALID).WithPublicDetail("k", "v").WithDetail("k", "v2")
+		if d := err.DetailsFor(sos.AudiencePublic); len(d) != 0 {
+			t.Errorf("details: got %v, want none", d)
+		}
+	})
+
+	t.Run("fallback follows code", func(t *testing.T) {
+		err := sos.New(sos.INVALID).WithMessage("internal only").WithCode(sos.NOTFOUND)
+		if got, want := err.PublicMessage(), sos.FallbackMessage(sos.NOTFOUND); got != want {
+			t.Errorf("public message: got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestHTTPRenderer(t *testing.T) {
+
+	cases := map[string]struct {
+		err    error
+		status int
+		detail string
+	}{
+		"sos error": {
+			err:    sos.New(sos.NOTFOUND).WithMessage("select * from users").WithPublicMessage("user not found"),
+			status: http.StatusNotFound,
+			detail: "user not found",
+		},
+		"foreign error": {
+			err:    fmt.Errorf("select * from users"),
+			status: http.StatusInternalServerError,
+			detail: sos.FallbackMessage(sos.INTERNAL),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			sos.HTTPRenderer{}.Render(rec, tc.err)
+
+			if rec.Code != tc.status {
+				t.Errorf("status: got %d, want %d", rec.Code, tc.status)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != sos.ProblemContentType {
+				t.Errorf("content type: got %q, want %q", ct, sos.ProblemContentType)
+			}
+
+			var p sos.Problem
+			if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Detail != tc.detail {
+				t.Errorf("detail: got %q, want %q", p.Detail, tc.detail)
+			}
+			if p.Trace != "" {
+				t.Errorf("trace should not be rendered publicly: %s", p.Trace)
+			}
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		var created int
+		t.Cleanup(sos.Observe(func(*sos.Err) { created++ }))
+
+		rec := httptest.NewRecorder()
+		sos.HTTPRenderer{}.Render(rec, nil)
+
+		if rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "" {
+			t.Errorf("nothing should be written: %q", rec.Body.String())
+		}
+		if created != 0 {
+			t.Errorf("no error should be created: got %d", created)
+		}
+	})
+}
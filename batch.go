@@ -0,0 +1,217 @@
+package sos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// RetryableCodes are the codes considered safe to retry by BatchResult.Retryable.
+var RetryableCodes = []Code{
+	TEMPORARY,
+	TIMEOUT,
+}
+
+// BatchItem is the outcome of a single item of a batch operation.
+type BatchItem struct {
+	// Key identifies the item by ID or index.
+	Key string
+	// Err is the item failure which is nil when the item succeeded.
+	Err *Err
+}
+
+// BatchResult records the per-item outcomes of a batch operation which may partially succeed.
+//
+// It is safe to use from concurrent goroutines and the zero value is ready to use.
+type BatchResult struct {
+	// Precedence overrides the package Precedence when picking the overall Code.
+	Precedence []Code
+
+	mu    sync.Mutex
+	items []BatchItem
+	index map[string]int
+}
+
+// Record records the outcome of the item identified by the key provided where a
+// nil error indicates success. Recording the same key again replaces its outcome.
+//
+// Errors which do not satisfy the Error interface are traced as INTERNAL errors.
+func (b *BatchResult) Record(key string, err error) {
+	item := BatchItem{Key: key}
+	if err != nil {
+		item.Err = As(traceErr(context.Background(), false, 0, err))
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.index == nil {
+		b.index = make(map[string]int)
+	}
+
+	if i, ok := b.index[key]; ok {
+		b.items[i] = item
+		return
+	}
+
+	b.index[key] = len(b.items)
+	b.items = append(b.items, item)
+}
+
+// RecordIndex records the outcome of the item at the index provided. See Record.
+func (b *BatchResult) RecordIndex(i int, err error) {
+	b.Record(strconv.Itoa(i), err)
+}
+
+// Items exposes the outcome of every item in the order they were first recorded.
+func (b *BatchResult) Items() []BatchItem {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	items := make([]BatchItem, len(b.items))
+	copy(items, b.items)
+
+	return items
+}
+
+// Failed exposes the outcome of every item which failed.
+func (b *BatchResult) Failed() []BatchItem {
+	var failed []BatchItem
+	for _, item := range b.Items() {
+		if item.Err != nil {
+			failed = append(failed, item)
+		}
+	}
+	return failed
+}
+
+// Retryable exposes the keys of the failed items whose Code is one of RetryableCodes.
+func (b *BatchResult) Retryable() []string {
+	var keys []string
+	for _, item := range b.Failed() {
+		for _, c := range RetryableCodes {
+			if item.Err.Code() == c {
+				keys = append(keys, item.Key)
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// Code summarizes the failures into the most significant Code according to the
+// precedence. If no item failed then an empty Code is returned.
+func (b *BatchResult) Code() Code {
+	failed := b.Failed()
+	if len(failed) == 0 {
+		return ""
+	}
+
+	codes := make([]Code, len(failed))
+	for i, item := range failed {
+		codes[i] = item.Err.Code()
+	}
+
+	return Prevailing(b.Precedence, codes...)
+}
+
+// Err summarizes the failures into a single error whose Code is the overall Code.
+//
+// If no item failed then the returned value is nil.
+func (b *BatchResult) Err() error {
+	failed := b.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	errs := make([]error, len(failed))
+	for i, item := range failed {
+		errs[i] = item.Err
+	}
+
+	msg := fmt.Sprintf("%d of %d items failed", len(failed), len(b.Items()))
+
+	return create(context.Background(), false, 1, b.Code(), msg, errors.Join(errs...))
+}
+
+// Status produces the HTTP status code summarizing the batch.
+//
+// It is 200 when every item succeeded, 207 Multi-Status when only some of them
+// failed and the status of the overall Code when every item failed.
+func (b *BatchResult) Status() int {
+	n, failed := len(b.Items()), len(b.Failed())
+	switch {
+	case failed == 0:
+		return http.StatusOK
+	case failed < n:
+		return http.StatusMultiStatus
+	}
+	return HTTPStatus(b.Code())
+}
+
+type batchJSON struct {
+	Status int             `json:"status"`
+	Code   Code            `json:"code,omitempty"`
+	Items  []batchItemJSON `json:"items"`
+}
+
+type batchItemJSON struct {
+	Key    string   `json:"key"`
+	Status int      `json:"status"`
+	Error  *Problem `json:"error,omitempty"`
+}
+
+func (b *BatchResult) view(ctx context.Context, a Audience) batchJSON {
+	items := b.Items()
+
+	v := batchJSON{
+		Status: b.Status(),
+		Code:   b.Code(),
+		Items:  make([]batchItemJSON, len(items)),
+	}
+
+	for i, item := range items {
+		v.Items[i] = batchItemJSON{Key: item.Key, Status: http.StatusOK}
+		if item.Err != nil {
+			p := item.Err.problem(ctx, a)
+			v.Items[i].Status = p.Status
+			v.Items[i].Error = &p
+		}
+	}
+
+	return v
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+//
+// The output is intended for the public audience so that it is safe to send to
+// clients. Use MarshalJSONFor to include internal messages and traces.
+func (b *BatchResult) MarshalJSON() ([]byte, error) {
+	return b.MarshalJSONFor(AudiencePublic)
+}
+
+// MarshalJSONFor produces the JSON representation of the batch result for the
+// audience provided. See Err.Problem.
+func (b *BatchResult) MarshalJSONFor(a Audience) ([]byte, error) {
+	return json.Marshal(b.view(context.Background(), a))
+}
+
+// RenderBatch writes the batch result provided to the response writer using the
+// status produced by BatchResult.Status and the per-item outcomes as the body.
+func (r HTTPRenderer) RenderBatch(w http.ResponseWriter, b *BatchResult) {
+	r.RenderBatchContext(context.Background(), w, b)
+}
+
+// RenderBatchContext writes the batch result provided to the response writer like
+// RenderBatch where the context, typically the one of the request, is passed to the hooks.
+func (r HTTPRenderer) RenderBatchContext(ctx context.Context, w http.ResponseWriter, b *BatchResult) {
+	v := b.view(ctx, r.Audience)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(v.Status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,102 @@
+package sos_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bjaus/sos"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBatchResult(t *testing.T) {
+
+	var b sos.BatchResult
+	b.RecordIndex(0, nil)
+	b.RecordIndex(1, sos.New(sos.NOTFOUND))
+	b.RecordIndex(2, sos.New(sos.TEMPORARY))
+	b.RecordIndex(3, errors.New("boom"))
+	b.Record("4", sos.New(sos.TIMEOUT))
+	b.Record("4", nil) // Retried successfully.
+
+	if got := b.Code(); got != sos.INTERNAL {
+		t.Errorf("code: got %q, want %q", got, sos.INTERNAL)
+	}
+	if got := b.Status(); got != http.StatusMultiStatus {
+		t.Errorf("status: got %d, want %d", got, http.StatusMultiStatus)
+	}
+	if diff := cmp.Diff(b.Retryable(), []string{"2"}); diff != "" {
+		t.Error(diff)
+	}
+	if got := sos.Kind(b.Err()); got != sos.INTERNAL {
+		t.Errorf("err: got %q, want %q", got, sos.INTERNAL)
+	}
+
+	rec := httptest.NewRecorder()
+	sos.HTTPRenderer{}.RenderBatch(rec, &b)
+
+	if rec.Code != http.StatusMultiStatus {
+		t.Errorf("rendered status: got %d, want %d", rec.Code, http.StatusMultiStatus)
+	}
+
+	var body struct {
+		Items []struct {
+			Key    string       `json:"key"`
+			Status int          `json:"status"`
+			Error  *sos.Problem `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int{http.StatusOK, http.StatusNotFound, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK}
+	for i, item := range body.Items {
+		if item.Status != want[i] {
+			t.Errorf("item %s: status: got %d, want %d", item.Key, item.Status, want[i])
+		}
+		if item.Error != nil && item.Error.Detail == "boom" {
+			t.Errorf("item %s: leaked internal message", item.Key)
+		}
+	}
+}
+
+func TestBatchResultJSON(t *testing.T) {
+
+	var b sos.BatchResult
+	b.Record("a", errors.New("pq: password authentication failed for 10.0.0.5"))
+
+	public, err := json.Marshal(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(public); strings.Contains(s, "10.0.0.5") || strings.Contains(s, "batch_test.go") {
+		t.Errorf("public: leaked internal data: %s", s)
+	}
+
+	internal, err := b.MarshalJSONFor(sos.AudienceInternal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(internal); !strings.Contains(s, "10.0.0.5") || !strings.Contains(s, "batch_test.go") {
+		t.Errorf("internal: missing trace: %s", s)
+	}
+}
+
+func TestBatchResultStatus(t *testing.T) {
+
+	var ok sos.BatchResult
+	ok.RecordIndex(0, nil)
+	if ok.Status() != http.StatusOK || ok.Err() != nil {
+		t.Errorf("all succeeded: got %d %v", ok.Status(), ok.Err())
+	}
+
+	var failed sos.BatchResult
+	failed.RecordIndex(0, sos.New(sos.NOTFOUND))
+	if failed.Status() != http.StatusNotFound {
+		t.Errorf("all failed: got %d, want %d", failed.Status(), http.StatusNotFound)
+	}
+}
@@ -0,0 +1,64 @@
+package sos_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bjaus/sos"
+)
+
+var sink error
+
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sink = sos.New(sos.NOTFOUND)
+	}
+}
+
+func BenchmarkNewWithoutCallers(b *testing.B) {
+	sos.SkipCallers[sos.NOTFOUND] = true
+	defer delete(sos.SkipCallers, sos.NOTFOUND)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sink = sos.New(sos.NOTFOUND)
+	}
+}
+
+func BenchmarkNewHelper(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sink = notFound()
+	}
+}
+
+func BenchmarkTrace(b *testing.B) {
+	err := sos.New(sos.NOTFOUND)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sink = sos.Trace(err)
+	}
+}
+
+func BenchmarkTraceForeign(b *testing.B) {
+	err := errors.New("boom")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sink = sos.Trace(err)
+	}
+}
+
+func BenchmarkError(b *testing.B) {
+	err := sos.Trace(sos.New(sos.NOTFOUND).WithError(errors.New("boom")))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = err.Error()
+	}
+}
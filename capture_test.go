@@ -0,0 +1,71 @@
+package sos_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bjaus/sos"
+)
+
+func TestCaptureCallers(t *testing.T) {
+
+	t.Run("lazy", func(t *testing.T) {
+		err := sos.New(sos.NOTFOUND)
+		want := line() - 1
+		if got := err.Operation().Line(); got != want {
+			t.Errorf("got line %d, want %d", got, want)
+		}
+		if got := err.Operation().Caller(); got != "TestCaptureCallers" {
+			t.Errorf("got caller %q", got)
+		}
+	})
+
+	t.Run("per code", func(t *testing.T) {
+		sos.SkipCallers[sos.NOTFOUND] = true
+		defer delete(sos.SkipCallers, sos.NOTFOUND)
+
+		err := sos.Trace(sos.New(sos.NOTFOUND).WithMessage("missing"))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if op := sos.As(err).Operation(); op != nil {
+			t.Errorf("unexpected op: %v", op)
+		}
+		if got, want := err.Error(), fmt.Sprintf("id: %s\n[not found] missing", sos.As(err).ID()); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+
+		if sos.New(sos.INTERNAL).Operation() == nil {
+			t.Error("expected op for other codes")
+		}
+	})
+
+	t.Run("global", func(t *testing.T) {
+		sos.CaptureCallers = false
+		defer func() { sos.CaptureCallers = true }()
+
+		err := sos.Trace(errors.New("boom"))
+		if op := sos.As(err).Operation(); op != nil {
+			t.Errorf("unexpected op: %v", op)
+		}
+		if got := err.Error(); !strings.HasSuffix(got, "\n[internal] boom") {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("without ids", func(t *testing.T) {
+		sos.CaptureCallers = false
+		gen := sos.IDGenerator
+		sos.IDGenerator = nil
+		defer func() {
+			sos.CaptureCallers = true
+			sos.IDGenerator = gen
+		}()
+
+		if err := sos.Trace(sos.New(sos.NOTFOUND)); err == nil {
+			t.Error("trace should keep the error")
+		}
+	})
+}
@@ -0,0 +1,72 @@
+// Command sos provides helpers for working with sos errors.
+//
+// Usage:
+//
+//	sos decrypt [-keys id:key,...] [token]
+//
+// The decrypt command turns a debug token rendered by sos.HTTPRenderer back into
+// the original error trace. Keys default to the SOS_DEBUG_KEYS environment variable
+// and the token is read from standard input when not provided as an argument.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bjaus/sos"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "sos:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 || args[0] != "decrypt" {
+		return fmt.Errorf("usage: sos decrypt [-keys id:key,...] [token]")
+	}
+
+	fs := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	keys := fs.String("keys", os.Getenv("SOS_DEBUG_KEYS"), "comma separated id:base64key pairs")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	kr, err := sos.ParseKeyring(*keys)
+	if err != nil {
+		return err
+	}
+
+	token := fs.Arg(0)
+	if token == "" {
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		token = strings.TrimSpace(line)
+	}
+
+	e, err := sos.Decrypt(kr, token)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, e.Error())
+
+	if d := e.Details(); len(d) > 0 {
+		b, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "\ndetails: %s\n", b)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/bjaus/sos"
+)
+
+func TestRun(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	keys := "k1:" + base64.StdEncoding.EncodeToString(key)
+
+	kr := sos.NewKeyring()
+	if err := kr.Add("k1", key); err != nil {
+		t.Fatal(err)
+	}
+	err := sos.New(sos.NOTFOUND).WithMessage("user 123 not found").WithDetail("table", "users")
+	token, e := sos.Encrypt(kr, err)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	cases := map[string]struct {
+		args    []string
+		env     string
+		stdin   string
+		want    []string
+		wantErr bool
+	}{
+		"argument": {
+			args: []string{"decrypt", "-keys", keys, token},
+			want: []string{"[not found] user 123 not found", `"table": "users"`},
+		},
+		"stdin": {
+			args:  []string{"decrypt", "-keys", keys},
+			stdin: token + "\n",
+			want:  []string{"[not found] user 123 not found"},
+		},
+		"environment": {
+			args: []string{"decrypt", token},
+			env:  keys,
+			want: []string{"[not found] user 123 not found"},
+		},
+		"usage": {
+			args:    []string{"encrypt"},
+			wantErr: true,
+		},
+		"wrong key": {
+			args:    []string{"decrypt", "-keys", "k1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{8}, 32)), token},
+			wantErr: true,
+		},
+		"invalid keys": {
+			args:    []string{"decrypt", "-keys", "nope", token},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("SOS_DEBUG_KEYS", tc.env)
+
+			var out bytes.Buffer
+			err := run(tc.args, strings.NewReader(tc.stdin), &out)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+			for _, w := range tc.want {
+				if !strings.Contains(out.String(), w) {
+					t.Errorf("missing %q in %q", w, out.String())
+				}
+			}
+		})
+	}
+}
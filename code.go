@@ -1,8 +1,25 @@
 package sos
 
 // Code indicates the error code.
+//
+// Code implements the error interface so that it can be used as the target of
+// errors.Is (i.e., errors.Is(err, sos.NOTFOUND)).
 type Code string
 
+// Error implements the error interface.
+func (c Code) Error() string {
+	return string(c)
+}
+
+// Reason is an error reason code which can be used as the target of errors.Is
+// (i.e., errors.Is(err, sos.Reason("card-declined"))).
+type Reason string
+
+// Error implements the error interface.
+func (r Reason) Error() string {
+	return string(r)
+}
+
 // Default error codes.
 const (
 	// INTERNAL indicates an error caused by internal failure.
@@ -0,0 +1,113 @@
+package sos
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+type collectorKey struct{}
+
+type collector struct {
+	mu   sync.Mutex
+	errs []*Err
+}
+
+// WithCollector returns a copy of the context holding an error collector used
+// to gather non-fatal errors with Collect.
+func WithCollector(ctx context.Context) context.Context {
+	return context.WithValue(ctx, collectorKey{}, new(collector))
+}
+
+// Collect traces the error provided and adds it to the collector held by the context.
+//
+// It is safe to call from concurrent goroutines sharing the same context. The
+// returned value reports whether the error was collected which is false when the
+// error is nil or the context holds no collector.
+func Collect(ctx context.Context, err error) bool {
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	if !ok || err == nil {
+		return false
+	}
+
+	e := As(traceErr(ctx, true, 0, err))
+	if e == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.errs = append(c.errs, e)
+
+	return true
+}
+
+// Collected exposes the errors collected in the context in the order they were collected.
+func Collected(ctx context.Context) []*Err {
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	if !ok {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	errs := make([]*Err, len(c.errs))
+	copy(errs, c.errs)
+
+	return errs
+}
+
+// CollectedProblems exposes the errors collected in the context as problem
+// details for the audience provided so they can be returned as warnings in a
+// response body.
+func CollectedProblems(ctx context.Context, a Audience) []Problem {
+	errs := Collected(ctx)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	problems := make([]Problem, len(errs))
+	for i, e := range errs {
+		problems[i] = e.problem(ctx, a)
+	}
+
+	return problems
+}
+
+// CollectorMiddleware adds an error collector to the context of every request and
+// logs the collected errors as a single structured event once the request completes.
+//
+// If the logger provided is nil then slog.Default is used.
+func CollectorMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithCollector(r.Context())
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+
+		errs := Collected(ctx)
+		if len(errs) == 0 {
+			return
+		}
+
+		l := logger
+		if l == nil {
+			l = slog.Default()
+		}
+
+		attrs := make([]any, len(errs))
+		for i, e := range errs {
+			attrs[i] = slog.Any(strconv.Itoa(i), e)
+		}
+
+		l.LogAttrs(ctx, slog.LevelWarn, "collected errors",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("count", len(errs)),
+			slog.Group("errors", attrs...),
+		)
+	})
+}
@@ -0,0 +1,81 @@
+package sos_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bjaus/sos"
+)
+
+func TestCollect(t *testing.T) {
+
+	if sos.Collect(context.Background(), errors.New("dropped")) {
+		t.Error("collect should report false without a collector")
+	}
+
+	ctx := sos.WithCollector(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sos.Collect(ctx, sos.New(sos.NOTFOUND).WithPublicMessage("item %d skipped", i))
+		}(i)
+	}
+	wg.Wait()
+
+	if sos.Collect(ctx, nil) {
+		t.Error("collect should report false for nil errors")
+	}
+
+	if n := len(sos.Collected(ctx)); n != 10 {
+		t.Errorf("collected: got %d, want 10", n)
+	}
+	if n := len(sos.CollectedProblems(ctx, sos.AudiencePublic)); n != 10 {
+		t.Errorf("problems: got %d, want 10", n)
+	}
+}
+
+func TestCollectorMiddleware(t *testing.T) {
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	h := sos.CollectorMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sos.Collect(r.Context(), fmt.Errorf("enrichment failed"))
+		sos.Collect(r.Context(), sos.New(sos.TIMEOUT).WithPublicMessage("pricing unavailable"))
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"warnings": sos.CollectedProblems(r.Context(), sos.AudiencePublic),
+		})
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	if !strings.Contains(rec.Body.String(), "pricing unavailable") {
+		t.Errorf("response should include warnings: %s", rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "enrichment failed") {
+		t.Errorf("response leaked internal message: %s", rec.Body.String())
+	}
+
+	if n := strings.Count(logs.String(), "\n"); n != 1 {
+		t.Fatalf("log events: got %d, want 1:\n%s", n, logs.String())
+	}
+	for _, want := range []string{`"count":2`, "enrichment failed", `"code":"timeout"`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log should contain %s:\n%s", want, logs.String())
+		}
+	}
+}
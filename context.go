@@ -0,0 +1,85 @@
+package sos
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Extractor produces error details from request metadata held in a context
+// such as request IDs, tenant IDs or trace IDs.
+type Extractor func(ctx context.Context) map[string]string
+
+var extractors struct {
+	mu   sync.RWMutex
+	next int
+	fns  []extractor
+}
+
+type extractor struct {
+	id int
+	fn Extractor
+}
+
+// RegisterExtractor registers an Extractor used by NewContext and TraceContext.
+//
+// The returned function unregisters the extractor.
+func RegisterExtractor(fn Extractor) (remove func()) {
+	extractors.mu.Lock()
+	defer extractors.mu.Unlock()
+
+	id := extractors.next
+	extractors.next++
+
+	fns := make([]extractor, len(extractors.fns), len(extractors.fns)+1)
+	copy(fns, extractors.fns)
+	extractors.fns = append(fns, extractor{id: id, fn: fn})
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			extractors.mu.Lock()
+			defer extractors.mu.Unlock()
+
+			fns := make([]extractor, 0, len(extractors.fns))
+			for _, x := range extractors.fns {
+				if x.id != id {
+					fns = append(fns, x)
+				}
+			}
+			extractors.fns = fns
+		})
+	}
+}
+
+// ValueExtractor creates an Extractor which adds the value stored in the context
+// under the key provided as the detail named k.
+//
+// Values which are not strings are formatted using fmt.Sprint.
+func ValueExtractor(key interface{}, k string) Extractor {
+	return func(ctx context.Context) map[string]string {
+		switch v := ctx.Value(key).(type) {
+		case nil:
+			return nil
+		case string:
+			return map[string]string{k: v}
+		default:
+			return map[string]string{k: fmt.Sprint(v)}
+		}
+	}
+}
+
+// extract adds the details produced by the registered extractors without
+// overwriting details which are already present.
+func (e *Err) extract(ctx context.Context) {
+	extractors.mu.RLock()
+	fns := extractors.fns
+	extractors.mu.RUnlock()
+
+	for _, x := range fns {
+		for _, a := range attrsOf(x.fn(ctx)) {
+			e.setDefault(a)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package sos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bjaus/sos"
+)
+
+type ctxkey string
+
+func TestNewContext(t *testing.T) {
+
+	t.Cleanup(sos.RegisterExtractor(sos.ValueExtractor(ctxkey("request"), "request_id")))
+	t.Cleanup(sos.RegisterExtractor(sos.ValueExtractor(ctxkey("tenant"), "tenant_id")))
+
+	ctx := context.WithValue(context.Background(), ctxkey("request"), "req-1")
+	ctx = context.WithValue(ctx, ctxkey("tenant"), 42)
+
+	t.Run("new", func(t *testing.T) {
+		err := sos.NewContext(ctx, sos.NOTFOUND)
+		if got := err.Details()["request_id"]; got != "req-1" {
+			t.Errorf("request_id: got %q, want %q", got, "req-1")
+		}
+		if got := err.Details()["tenant_id"]; got != "42" {
+			t.Errorf("tenant_id: got %q, want %q", got, "42")
+		}
+		if got := err.Operation().Caller(); got != "TestNewContext" {
+			t.Errorf("caller: got %q, want %q", got, "TestNewContext")
+		}
+	})
+
+	t.Run("trace", func(t *testing.T) {
+		err := sos.New(sos.INVALID).WithDetail("request_id", "explicit")
+		e := sos.As(sos.TraceContext(ctx, err))
+		if got := e.Details()["request_id"]; got != "explicit" {
+			t.Errorf("request_id should not be overwritten: got %q", got)
+		}
+		if got := e.Details()["tenant_id"]; got != "42" {
+			t.Errorf("tenant_id: got %q, want %q", got, "42")
+		}
+
+		e = sos.As(sos.TraceContext(ctx, errors.New("foreign")))
+		if got := e.Details()["request_id"]; got != "req-1" {
+			t.Errorf("request_id: got %q, want %q", got, "req-1")
+		}
+	})
+
+	t.Run("without context", func(t *testing.T) {
+		if d := sos.New(sos.INVALID).Details(); len(d) != 0 {
+			t.Errorf("details: got %v, want none", d)
+		}
+	})
+}
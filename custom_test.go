@@ -0,0 +1,144 @@
+package sos_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bjaus/sos"
+)
+
+// legacy is a custom sos.Error implementation owned by another team.
+type legacy struct {
+	ops []sos.Op
+}
+
+func (l *legacy) Error() string              { return "legacy failure" }
+func (l *legacy) Code() sos.Code             { return sos.CONFLICT }
+func (l *legacy) Details() map[string]string { return map[string]string{"version": "7"} }
+func (l *legacy) Message() string            { return "version conflict" }
+func (l *legacy) Reason() string             { return "stale-version" }
+func (l *legacy) Operation() sos.Op {
+	if len(l.ops) == 0 {
+		return nil
+	}
+	return l.ops[0]
+}
+
+// traceable additionally records the hops it is traced through.
+type traceable struct{ legacy }
+
+func (t *traceable) AddOperation(op sos.Op) { t.ops = append([]sos.Op{op}, t.ops...) }
+func (t *traceable) Operations() []sos.Op   { return t.ops }
+
+func TestCustomError(t *testing.T) {
+
+	t.Run("wrapped", func(t *testing.T) {
+		err := sos.Trace(&legacy{})
+
+		e := sos.As(err)
+		if e == nil {
+			t.Fatal("should be an sos error")
+		}
+		if e.Code() != sos.CONFLICT {
+			t.Errorf("code: got %q, want %q", e.Code(), sos.CONFLICT)
+		}
+		if e.Reason() != "stale-version" {
+			t.Errorf("reason: got %q, want %q", e.Reason(), "stale-version")
+		}
+		if e.Message() != "version conflict" {
+			t.Errorf("message: got %q, want %q", e.Message(), "version conflict")
+		}
+		if e.Details()["version"] != "7" {
+			t.Errorf("details: got %v", e.Details())
+		}
+		if e.Operation() == nil || e.Operation().Caller() != "TestCustomError" {
+			t.Errorf("op: got %v", e.Operation())
+		}
+
+		var l *legacy
+		if !errors.As(err, &l) {
+			t.Error("custom error should remain in the chain")
+		}
+	})
+
+	t.Run("traceable", func(t *testing.T) {
+		var err error = &traceable{}
+		err = sos.Trace(err)
+		err = sos.Trace(err)
+
+		x, ok := err.(*traceable)
+		if !ok {
+			t.Fatalf("traceable errors should be returned as is: %T", err)
+		}
+		if len(x.ops) != 2 {
+			t.Fatalf("ops: got %d, want 2", len(x.ops))
+		}
+
+		if got := sos.Kind(err); got != sos.CONFLICT {
+			t.Errorf("kind: got %q, want %q", got, sos.CONFLICT)
+		}
+
+		trace := sos.As(err).Error()
+		if n := strings.Count(trace, "custom_test.go"); n != 2 {
+			t.Errorf("trace should include both hops:\n%s", trace)
+		}
+	})
+	t.Run("view", func(t *testing.T) {
+		gen := sos.IDGenerator
+		t.Cleanup(func() { sos.IDGenerator = gen })
+
+		var n int
+		sos.IDGenerator = func() string {
+			n++
+			return "id"
+		}
+
+		err := &traceable{}
+		if a, b := sos.As(err), sos.As(err); a.ID() != b.ID() {
+			t.Errorf("id: got %q and %q", a.ID(), b.ID())
+		}
+		if n != 0 {
+			t.Errorf("ids generated: got %d, want 0", n)
+		}
+
+		defer func() {
+			if recover() == nil {
+				t.Error("must should panic for custom errors")
+			}
+		}()
+		sos.Must(err)
+	})
+}
+
+func TestCustomErrorsIs(t *testing.T) {
+
+	cases := map[string]struct {
+		err  error
+		want bool
+	}{
+		"custom": {
+			err:  &legacy{},
+			want: false,
+		},
+		"traced": {
+			err:  sos.Trace(&legacy{}),
+			want: true,
+		},
+		"traceable": {
+			err:  sos.Trace(&traceable{}),
+			want: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := errors.Is(tc.err, sos.CONFLICT); got != tc.want {
+				t.Errorf("is: got %v, want %v", got, tc.want)
+			}
+			if !sos.HasCode(tc.err, sos.CONFLICT) {
+				t.Error("has code: got false, want true")
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package sos
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// debugVersion prefixes every debug token so the format can evolve.
+const debugVersion = "sos1"
+
+// Keyring holds the AES-GCM keys used to encrypt and decrypt debug tokens.
+//
+// The most recently added key is used for encryption while every key in the
+// keyring can be used for decryption which allows keys to be rotated.
+type Keyring struct {
+	mu   sync.RWMutex
+	keys map[string]cipher.AEAD
+	// order holds the key ids from oldest to newest where the last is used for encryption.
+	order []string
+}
+
+// NewKeyring creates an empty keyring.
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string]cipher.AEAD)}
+}
+
+// ParseKeyring creates a keyring from a comma separated list of id:key pairs
+// where each key is base64 encoded (i.e., "2024a:BASE64,2024b:BASE64").
+//
+// The last key in the list is used for encryption.
+func ParseKeyring(s string) (*Keyring, error) {
+	k := NewKeyring()
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, enc, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid key %q: expected id:key", pair)
+		}
+		key, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", id, err)
+		}
+		if err := k.Add(id, key); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// Add adds an AES key (16, 24 or 32 bytes) to the keyring and makes it the key used for encryption.
+func (k *Keyring) Add(id string, key []byte) error {
+	if id == "" || strings.Contains(id, ".") {
+		return fmt.Errorf("invalid key id %q", id)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.keys == nil {
+		k.keys = make(map[string]cipher.AEAD)
+	}
+	k.keys[id] = aead
+	k.order = append(without(k.order, id), id)
+
+	return nil
+}
+
+// Remove removes a key from the keyring so that tokens encrypted with it can no longer be decrypted.
+//
+// When the key used for encryption is removed the newest remaining key takes its place.
+func (k *Keyring) Remove(id string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	delete(k.keys, id)
+	k.order = without(k.order, id)
+}
+
+// without produces the ids other than the one provided.
+func without(ids []string, id string) []string {
+	out := ids[:0:0]
+	for _, x := range ids {
+		if x != id {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+func (k *Keyring) seal(plaintext []byte) (string, error) {
+	k.mu.RLock()
+	var id string
+	if n := len(k.order); n > 0 {
+		id = k.order[n-1]
+	}
+	aead := k.keys[id]
+	k.mu.RUnlock()
+
+	if aead == nil {
+		return "", errors.New("keyring has no encryption key")
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	header := debugVersion + "." + id
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(header))
+
+	return header + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (k *Keyring) open(token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != debugVersion {
+		return nil, errors.New("invalid debug token")
+	}
+
+	k.mu.RLock()
+	aead := k.keys[parts[1]]
+	k.mu.RUnlock()
+
+	if aead == nil {
+		return nil, fmt.Errorf("unknown debug key %q", parts[1])
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid debug token: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("invalid debug token")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, []byte(parts[0]+"."+parts[1]))
+}
+
+type debugPayload struct {
+	ID     string     `json:"id,omitempty"`
+	Hops   []debugHop `json:"hops"`
+	Origin string     `json:"origin,omitempty"`
+}
+
+type debugHop struct {
+	Code    Code       `json:"code"`
+	Message string     `json:"message"`
+	Public  string     `json:"public,omitempty"`
+	Reason  string     `json:"reason"`
+	Details DetailList `json:"details,omitempty"`
+	// Visibility holds the audience of the details which are not internal.
+	Visibility map[string]Audience `json:"visibility,omitempty"`
+	Package    string              `json:"package,omitempty"`
+	Caller     string              `json:"caller,omitempty"`
+	File       string              `json:"file,omitempty"`
+	Line       int                 `json:"line,omitempty"`
+}
+
+// Encrypt produces an opaque debug token holding the full trace, details and
+// frames of the error provided.
+//
+// The token is safe to send to clients and can be turned back into an error
+// using Decrypt with a keyring holding the same key.
+func Encrypt(k *Keyring, err error) (string, error) {
+	var p debugPayload
+
+	if x, ok := find(err).(*Err); ok {
+		p.ID = x.id
+	}
+
+	Walk(err, func(_ int, w error) bool {
+		if _, ok := w.(Error); !ok && !unwraps(w) {
+			p.Origin = redactText(w.Error())
+		}
+		return true
+	})
+
+	// Hops are stored starting with the most recent.
+	hops := hopsOf(err, true)
+	for i := len(hops) - 1; i >= 0; i-- {
+		x := hops[i]
+		h := debugHop{
+			Code:    x.code,
+			Message: x.message,
+			Public:  x.public,
+			Reason:  x.reason,
+			Details: x.details,
+
+			Visibility: x.vis,
+		}
+		if o := x.op; o != nil {
+			h.Package = o.Package()
+			h.Caller = o.Caller()
+			h.File = o.File()
+			h.Line = o.Line()
+		}
+		p.Hops = append(p.Hops, h)
+	}
+
+	b, e := json.Marshal(p)
+	if e != nil {
+		return "", e
+	}
+
+	return k.seal(b)
+}
+
+// Decrypt turns a debug token produced by Encrypt back into an error whose
+// trace matches the trace of the original error.
+func Decrypt(k *Keyring, token string) (*Err, error) {
+	b, err := k.open(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var p debugPayload
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Hops) == 0 {
+		return nil, errors.New("debug token holds no error")
+	}
+
+	var cause error
+	if p.Origin != "" {
+		cause = errors.New(p.Origin)
+	}
+
+	var e *Err
+	for i := len(p.Hops) - 1; i >= 0; i-- {
+		h := p.Hops[i]
+		e = &Err{
+			id:      p.ID,
+			code:    h.Code,
+			message: h.Message,
+			public:  h.Public,
+			reason:  h.Reason,
+			err:     cause,
+		}
+		for _, d := range h.Details {
+			vis, ok := h.Visibility[d.Key]
+			if !ok {
+				vis = AudienceInternal
+			}
+			e.attrs = append(e.attrs, attr{key: d.Key, val: d.Value, vis: vis})
+		}
+		e.op = &op{pkg: h.Package, fn: h.Caller, file: h.File, line: h.Line}
+		cause = e
+	}
+
+	return e, nil
+}
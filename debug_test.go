@@ -0,0 +1,138 @@
+package sos_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bjaus/sos"
+)
+
+func TestDebugToken(t *testing.T) {
+
+	kr := sos.NewKeyring()
+	if err := kr.Add("old", bytes.Repeat([]byte{1}, 32)); err != nil {
+		t.Fatal(err)
+	}
+
+	err := sos.New(sos.NOTFOUND).
+		WithError(errors.New("sql: no rows in result set")).
+		WithMessage("user %d not found", 123).
+		WithDetail("table", "users")
+	err = sos.Must(sos.Trace(err))
+
+	token, e := sos.Encrypt(kr, err)
+	if e != nil {
+		t.Fatal(e)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("unexpected token format: %s", token)
+	}
+	payload, e := base64.RawURLEncoding.DecodeString(parts[2])
+	if e != nil {
+		t.Fatal(e)
+	}
+	if bytes.Contains(payload, []byte("users")) || bytes.Contains(payload, []byte("sql: no rows")) {
+		t.Fatalf("token is not opaque: %s", token)
+	}
+
+	// Rotate the key and ensure that tokens created with the old key still decrypt.
+	if err := kr.Add("new", bytes.Repeat([]byte{2}, 32)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, e := sos.Decrypt(kr, token)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if got.Error() != err.Error() {
+		t.Errorf("trace: got\n%s\nwant\n%s", got.Error(), err.Error())
+	}
+	if got.Details()["table"] != "users" {
+		t.Errorf("details: got %v", got.Details())
+	}
+
+	kr.Remove("old")
+	if _, e := sos.Decrypt(kr, token); e == nil {
+		t.Error("decrypt should fail once the key is removed")
+	}
+
+	// Removing the encryption key promotes the newest remaining key.
+	if err := kr.Add("newer", bytes.Repeat([]byte{3}, 32)); err != nil {
+		t.Fatal(err)
+	}
+	kr.Remove("newer")
+	token, e = sos.Encrypt(kr, err)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !strings.HasPrefix(token, "sos1.new.") {
+		t.Errorf("token should use the newest remaining key: %s", token)
+	}
+
+	kr.Remove("new")
+	if _, e := sos.Encrypt(kr, err); e == nil {
+		t.Error("encrypt should fail once every key is removed")
+	}
+}
+
+func TestDebugTokenAudience(t *testing.T) {
+
+	kr := sos.NewKeyring()
+	if err := kr.Add("k", bytes.Repeat([]byte{1}, 32)); err != nil {
+		t.Fatal(err)
+	}
+
+	err := sos.New(sos.INVALID).
+		WithDetail("table", "users").
+		WithPartnerDetail("tenant", "acme").
+		WithPublicDetail("field", "email")
+
+	token, e := sos.Encrypt(kr, err)
+	if e != nil {
+		t.Fatal(e)
+	}
+	got, e := sos.Decrypt(kr, token)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	for _, a := range []sos.Audience{sos.AudiencePublic, sos.AudiencePartner, sos.AudienceInternal} {
+		if g, w := got.DetailsFor(a), err.DetailsFor(a); !reflect.DeepEqual(g, w) {
+			t.Errorf("%s: got %v, want %v", a, g, w)
+		}
+	}
+}
+
+func TestHTTPRendererDebug(t *testing.T) {
+
+	kr, err := sos.ParseKeyring("k1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	sos.HTTPRenderer{Debug: kr}.Render(rec, sos.New(sos.INTERNAL).WithMessage("db.internal unreachable"))
+
+	var p sos.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Debug == "" {
+		t.Fatal("debug token should be rendered")
+	}
+
+	e, err := sos.Decrypt(kr, p.Debug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Message() != "db.internal unreachable" {
+		t.Errorf("message: got %q", e.Message())
+	}
+}
@@ -0,0 +1,305 @@
+package sos
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Detail is a single typed error detail.
+type Detail struct {
+	Key   string
+	Value interface{}
+}
+
+// DetailList is an ordered list of details.
+//
+// It marshals to a JSON object whose keys are kept in order and whose values keep
+// their types (i.e., numbers, booleans and nested objects).
+type DetailList []Detail
+
+// Map produces the string view of the details.
+func (d DetailList) Map() map[string]string {
+	m := make(map[string]string, len(d))
+	for _, x := range d {
+		m[x.Key] = stringify(x.Value)
+	}
+	return m
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (d DetailList) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteByte('{')
+	for i, x := range d {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		k, err := json.Marshal(x.Key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(jsonValue(x.Value))
+		if err != nil {
+			// Values such as channels and functions are kept as text so that
+			// the error can still be rendered.
+			if v, err = json.Marshal(stringify(x.Value)); err != nil {
+				return nil, err
+			}
+		}
+
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+
+	return b.Bytes(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface keeping the order of the keys.
+func (d *DetailList) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		*d = nil
+		return nil
+	}
+	if t != json.Delim('{') {
+		return fmt.Errorf("details: expected object, got %v", t)
+	}
+
+	var list DetailList
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				v = i
+			} else if f, err := n.Float64(); err == nil {
+				v = f
+			}
+		}
+		list = append(list, Detail{Key: t.(string), Value: v})
+	}
+
+	*d = list
+
+	return nil
+}
+
+// attr is a single error detail along with how it may be rendered.
+type attr struct {
+	key       string
+	val       interface{}
+	vis       Audience
+	sensitive bool
+}
+
+// attrsOf converts a string details map into attributes sorted by key so that the
+// resulting order is deterministic.
+func attrsOf(d map[string]string) []attr {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]attr, len(keys))
+	for i, k := range keys {
+		attrs[i] = attr{key: k, val: d[k], vis: AudienceInternal}
+	}
+
+	return attrs
+}
+
+func (e *Err) lookup(k string) (*attr, bool) {
+	for i := range e.attrs {
+		if e.attrs[i].key == k {
+			return &e.attrs[i], true
+		}
+	}
+	return nil, false
+}
+
+// set adds the attribute or replaces the value and visibility of an existing
+// attribute with the same key without changing its position. Attributes marked
+// sensitive remain sensitive.
+func (e *Err) set(a attr) {
+	if x, ok := e.lookup(a.key); ok {
+		x.val = a.val
+		x.vis = a.vis
+		x.sensitive = x.sensitive || a.sensitive
+		return
+	}
+	e.attrs = append(e.attrs, a)
+}
+
+// setDefault adds the attribute only if no attribute with the same key exists.
+func (e *Err) setDefault(a attr) {
+	if _, ok := e.lookup(a.key); !ok {
+		e.attrs = append(e.attrs, a)
+	}
+}
+
+// detailsOf produces the details of any Error implementation visible to the
+// audience provided. Details of custom implementations are considered internal.
+func detailsOf(x Error, a Audience) DetailList {
+	if e, ok := x.(*Err); ok {
+		return e.OrderedDetails(a)
+	}
+	if !a.allows(AudienceInternal) {
+		return DetailList{}
+	}
+	attrs := attrsOf(x.Details())
+	d := make(DetailList, len(attrs))
+	for i, x := range attrs {
+		d[i] = Detail{Key: x.key, Value: x.redacted()}
+	}
+	return d
+}
+
+// Detail exposes the typed value of a detail.
+func (e *Err) Detail(k string) (interface{}, bool) {
+	if x, ok := e.lookup(k); ok {
+		return x.val, true
+	}
+	return nil, false
+}
+
+// DetailString exposes the string view of a detail.
+func (e *Err) DetailString(k string) (string, bool) {
+	if v, ok := e.Detail(k); ok {
+		return stringify(v), true
+	}
+	return "", false
+}
+
+// DetailInt exposes a detail holding an integer.
+//
+// Unsigned values which do not fit in an int64 are not reported; use DetailFloat or
+// Detail to access them.
+func (e *Err) DetailInt(k string) (int64, bool) {
+	v, _ := e.Detail(k)
+	switch v := v.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		if uint64(v) <= math.MaxInt64 {
+			return int64(v), true
+		}
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+// DetailFloat exposes a detail holding a number.
+func (e *Err) DetailFloat(k string) (float64, bool) {
+	v, _ := e.Detail(k)
+	switch v := v.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	if i, ok := e.DetailInt(k); ok {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+// DetailBool exposes a detail holding a boolean.
+func (e *Err) DetailBool(k string) (bool, bool) {
+	v, _ := e.Detail(k)
+	b, ok := v.(bool)
+	return b, ok
+}
+
+// DetailDuration exposes a detail holding a time.Duration.
+func (e *Err) DetailDuration(k string) (time.Duration, bool) {
+	v, _ := e.Detail(k)
+	d, ok := v.(time.Duration)
+	return d, ok
+}
+
+// DetailTime exposes a detail holding a time.Time.
+func (e *Err) DetailTime(k string) (time.Time, bool) {
+	v, _ := e.Detail(k)
+	t, ok := v.(time.Time)
+	return t, ok
+}
+
+// stringify produces the string view of a detail value.
+func stringify(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case time.Duration:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case fmt.Stringer:
+		return v.String()
+	case error:
+		return v.Error()
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// jsonValue converts detail values which don't marshal to a meaningful JSON value.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case time.Duration:
+		return v.String()
+	case error:
+		return v.Error()
+	}
+	return v
+}
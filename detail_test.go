@@ -0,0 +1,124 @@
+package sos_test
+
+import (
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bjaus/sos"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTypedDetails(t *testing.T) {
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	err := sos.New(sos.TIMEOUT).
+		WithDetail("zone", "us-east-1").
+		WithDetailInt("attempts", 3).
+		WithDetailFloat("ratio", 0.5).
+		WithDetailBool("cached", true).
+		WithDetailDuration("elapsed", 1500*time.Millisecond).
+		WithDetailTime("deadline", at).
+		WithDetailAny("limits", map[string]int{"max": 10})
+
+	t.Run("getters", func(t *testing.T) {
+		if v, ok := err.DetailInt("attempts"); !ok || v != 3 {
+			t.Errorf("attempts: got %v %t", v, ok)
+		}
+		if v, ok := err.DetailFloat("ratio"); !ok || v != 0.5 {
+			t.Errorf("ratio: got %v %t", v, ok)
+		}
+		if v, ok := err.DetailBool("cached"); !ok || !v {
+			t.Errorf("cached: got %v %t", v, ok)
+		}
+		if v, ok := err.DetailDuration("elapsed"); !ok || v != 1500*time.Millisecond {
+			t.Errorf("elapsed: got %v %t", v, ok)
+		}
+		if v, ok := err.DetailTime("deadline"); !ok || !v.Equal(at) {
+			t.Errorf("deadline: got %v %t", v, ok)
+		}
+		if _, ok := err.DetailInt("zone"); ok {
+			t.Error("zone should not be an integer")
+		}
+		if _, ok := err.Detail("missing"); ok {
+			t.Error("missing should not be found")
+		}
+	})
+
+	t.Run("unsigned overflow", func(t *testing.T) {
+		e := sos.New(sos.INVALID).WithDetailAny("big", uint64(math.MaxUint64)).WithDetailAny("small", uint64(7))
+		if v, ok := e.DetailInt("big"); ok {
+			t.Errorf("big should not fit in an int64: got %d", v)
+		}
+		if v, ok := e.DetailFloat("big"); !ok || v != float64(math.MaxUint64) {
+			t.Errorf("big: got %v %t", v, ok)
+		}
+		if v, ok := e.DetailInt("small"); !ok || v != 7 {
+			t.Errorf("small: got %v %t", v, ok)
+		}
+	})
+
+	t.Run("string view", func(t *testing.T) {
+		want := map[string]string{
+			"zone":     "us-east-1",
+			"attempts": "3",
+			"ratio":    "0.5",
+			"cached":   "true",
+			"elapsed":  "1.5s",
+			"deadline": "2024-01-02T03:04:05Z",
+			"limits":   `{"max":10}`,
+		}
+		if diff := cmp.Diff(err.Details(), want); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		b, e := json.Marshal(err.OrderedDetails(sos.AudienceInternal))
+		if e != nil {
+			t.Fatal(e)
+		}
+		want := `{"zone":"us-east-1","attempts":3,"ratio":0.5,"cached":true,"elapsed":"1.5s","deadline":"2024-01-02T03:04:05Z","limits":{"max":10}}`
+		if string(b) != want {
+			t.Errorf("got %s, want %s", b, want)
+		}
+
+		var d sos.DetailList
+		if err := json.Unmarshal(b, &d); err != nil {
+			t.Fatal(err)
+		}
+		if d[0].Key != "zone" || d[1].Value != int64(3) {
+			t.Errorf("decoded order or types lost: %v", d)
+		}
+	})
+
+	t.Run("unserializable", func(t *testing.T) {
+		e := sos.New(sos.INVALID).WithDetailAny("done", make(chan struct{})).WithDetail("zone", "eu")
+
+		if _, err := json.Marshal(e); err != nil {
+			t.Fatal(err)
+		}
+
+		rec := httptest.NewRecorder()
+		sos.HTTPRenderer{Audience: sos.AudienceInternal}.Render(rec, e)
+
+		var p sos.Problem
+		if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+		if p.Code != sos.INVALID || len(p.Details) != 2 {
+			t.Errorf("got %+v", p)
+		}
+	})
+
+	t.Run("replace keeps order", func(t *testing.T) {
+		e := sos.New(sos.INVALID).WithDetail("a", "1").WithDetail("b", "2").WithDetailInt("a", 3)
+		d := e.OrderedDetails(sos.AudienceInternal)
+		if d[0].Key != "a" || d[0].Value != int64(3) || d[1].Key != "b" {
+			t.Errorf("got %v", d)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package sos
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Fingerprinter produces stable hashes used to group occurrences of the same error.
+//
+// The fingerprint is made up of the Code and reason of the error along with the
+// normalized chain of call sites. Messages and detail values are ignored so that
+// parameters such as IDs don't make each occurrence look unique.
+type Fingerprinter struct {
+	// Lines includes line numbers in the call sites which makes fingerprints
+	// change whenever code around the call sites moves.
+	Lines bool
+}
+
+// DefaultFingerprinter is the Fingerprinter used by Fingerprint.
+var DefaultFingerprinter = Fingerprinter{}
+
+// Fingerprint produces the fingerprint of the error provided using DefaultFingerprinter.
+//
+// If the error provided is nil then an empty string is returned.
+func Fingerprint(err error) string {
+	return DefaultFingerprinter.Fingerprint(err)
+}
+
+// Fingerprint produces the fingerprint of the error provided.
+//
+// If the error provided is nil then an empty string is returned.
+func (f Fingerprinter) Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var b strings.Builder
+
+	if e := find(err); e != nil {
+		fmt.Fprintf(&b, "%s\n%s\n", e.Code(), e.Reason())
+	}
+
+	var prev string
+	Walk(err, func(_ int, w error) bool {
+		x, ok := w.(Error)
+		if !ok {
+			if !unwraps(w) {
+				fmt.Fprintf(&b, "%T\n", w) // The origin type is stable while its message is not.
+			}
+			return true
+		}
+
+		for _, op := range operations(x) {
+			site := op.Package() + "." + op.Caller()
+			if f.Lines {
+				site = fmt.Sprintf("%s:%d", site, op.Line())
+			}
+
+			// Traced copies of the same error share call sites so only record changes.
+			if site != prev {
+				fmt.Fprintln(&b, site)
+				prev = site
+			}
+		}
+
+		return true
+	})
+
+	sum := sha256.Sum256([]byte(b.String()))
+
+	return hex.EncodeToString(sum[:16])
+}
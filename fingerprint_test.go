@@ -0,0 +1,46 @@
+package sos_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bjaus/sos"
+)
+
+func lookup(id int) error {
+	err := sos.New(sos.NOTFOUND).
+		WithError(fmt.Errorf("no rows for %d", id)).
+		WithMessage("user %d not found", id).
+		WithDetail("id", fmt.Sprint(id))
+	return sos.Trace(err)
+}
+
+func TestFingerprint(t *testing.T) {
+
+	a, b := lookup(1), lookup(2)
+
+	if a.Error() == b.Error() {
+		t.Fatal("traces should differ by message")
+	}
+	if sos.Fingerprint(a) != sos.Fingerprint(b) {
+		t.Errorf("fingerprints should match: %s != %s", sos.Fingerprint(a), sos.Fingerprint(b))
+	}
+
+	cases := map[string]error{
+		"code":      sos.Must(lookup(1)).WithCode(sos.INTERNAL),
+		"reason":    sos.Must(lookup(1)).WithReason("deleted"),
+		"call site": sos.New(sos.NOTFOUND),
+	}
+
+	for name, err := range cases {
+		t.Run(name, func(t *testing.T) {
+			if sos.Fingerprint(err) == sos.Fingerprint(a) {
+				t.Errorf("fingerprint should differ by %s", name)
+			}
+		})
+	}
+
+	if sos.Fingerprint(nil) != "" {
+		t.Error("nil error should have an empty fingerprint")
+	}
+}
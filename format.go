@@ -0,0 +1,199 @@
+package sos
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TraceFormatter produces the text representation of an error trace.
+type TraceFormatter interface {
+	Format(t TraceInfo) string
+}
+
+// DefaultTraceFormatter is the TraceFormatter used by Err.Error.
+var DefaultTraceFormatter TraceFormatter = TextFormatter{}
+
+// FormatTrace formats the trace of the error provided using the formatter provided.
+//
+// If the formatter is nil then DefaultTraceFormatter is used.
+func FormatTrace(err error, f TraceFormatter) string {
+	if f == nil {
+		f = DefaultTraceFormatter
+	}
+	if f == nil {
+		f = TextFormatter{}
+	}
+	return f.Format(traceOf(err, formatsDetails(f)))
+}
+
+// formatsDetails reports whether the formatter provided includes the details of the
+// hops. Only the built-in formatters are known to omit them.
+func formatsDetails(f TraceFormatter) bool {
+	switch f.(type) {
+	case TextFormatter, LogfmtFormatter, CompilerFormatter, TTYFormatter:
+		return false
+	}
+	return true
+}
+
+var (
+	_ TraceFormatter = TextFormatter{}
+	_ TraceFormatter = JSONFormatter{}
+	_ TraceFormatter = LogfmtFormatter{}
+	_ TraceFormatter = CompilerFormatter{}
+)
+
+// truncatedMarker precedes the oldest hop of traces which were cut short by MaxDepth.
+const truncatedMarker = "... (truncated)"
+
+// TextFormatter produces the multi-line trace with a "[code] message" header per
+// hop followed by its tab-indented call sites.
+type TextFormatter struct{}
+
+// Format implements the TraceFormatter interface.
+func (TextFormatter) Format(t TraceInfo) string {
+	var b strings.Builder
+
+	for _, h := range t.Hops {
+		fmt.Fprintf(&b, "[%s] %s", h.Code, h.Message)
+		for _, f := range h.Frames {
+			fmt.Fprintf(&b, "\n\t%s", location(f))
+		}
+		fmt.Fprint(&b, "\n")
+	}
+
+	s := strings.TrimSpace(b.String())
+
+	for i := len(t.Origins) - 1; i >= 0; i-- {
+		if o := t.Origins[i]; !strings.Contains(s, o) {
+			s = fmt.Sprintf("%s\n%s", o, s)
+		}
+	}
+
+	if t.Truncated {
+		s = truncatedMarker + "\n" + s
+	}
+
+	if t.ID != "" {
+		s = fmt.Sprintf("id: %s\n%s", t.ID, s)
+	}
+
+	return s
+}
+
+// JSONFormatter produces a JSON array of hops ordered from the origin of the error.
+type JSONFormatter struct {
+	// Indent, when provided, is used to indent the output.
+	Indent string
+}
+
+type jsonHop struct {
+	Code    Code        `json:"code"`
+	Message string      `json:"message"`
+	Reason  string      `json:"reason"`
+	Details DetailList  `json:"details,omitempty"`
+	Frames  []jsonFrame `json:"frames"`
+}
+
+type jsonFrame struct {
+	Package string `json:"package"`
+	Caller  string `json:"caller"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+}
+
+// Format implements the TraceFormatter interface.
+func (f JSONFormatter) Format(t TraceInfo) string {
+	v := make([]jsonHop, len(t.Hops))
+
+	for i, h := range t.Hops {
+		v[i] = jsonHop{Code: h.Code, Message: h.Message, Reason: h.Reason, Details: h.Details, Frames: make([]jsonFrame, len(h.Frames))}
+		for j, op := range h.Frames {
+			v[i].Frames[j] = jsonFrame{Package: op.Package(), Caller: op.Caller(), File: TrimPaths.Trim(op.File()), Line: op.Line()}
+		}
+	}
+
+	var b []byte
+	if f.Indent != "" {
+		b, _ = json.MarshalIndent(v, "", f.Indent)
+	} else {
+		b, _ = json.Marshal(v)
+	}
+
+	return string(b)
+}
+
+// LogfmtFormatter produces a single line of logfmt key-value pairs.
+//
+// Hops are numbered from the origin (i.e., hop0.code="not found" hop0.frames="a.go:1,b.go:2").
+type LogfmtFormatter struct{}
+
+// Format implements the TraceFormatter interface.
+func (LogfmtFormatter) Format(t TraceInfo) string {
+	var pairs []string
+
+	add := func(k, v string) {
+		pairs = append(pairs, k+"="+logfmtValue(v))
+	}
+
+	if t.ID != "" {
+		add("id", t.ID)
+	}
+	if t.Truncated {
+		add("truncated", "true")
+	}
+	for _, o := range t.Origins {
+		add("origin", o)
+	}
+	for i, h := range t.Hops {
+		prefix := "hop" + strconv.Itoa(i) + "."
+
+		frames := make([]string, len(h.Frames))
+		for j, f := range h.Frames {
+			frames[j] = location(f)
+		}
+
+		add(prefix+"code", string(h.Code))
+		add(prefix+"reason", h.Reason)
+		add(prefix+"message", h.Message)
+		add(prefix+"frames", strings.Join(frames, ","))
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+func logfmtValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " =\"\t\r\n\\") {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+// CompilerFormatter produces "file:line: [code] message" lines, one per call site,
+// which can be loaded into editor quickfix lists.
+type CompilerFormatter struct{}
+
+// Format implements the TraceFormatter interface.
+func (CompilerFormatter) Format(t TraceInfo) string {
+	var lines []string
+
+	for _, h := range t.Hops {
+		msg := strings.ReplaceAll(h.Message, "\n", " ")
+		for _, f := range h.Frames {
+			lines = append(lines, fmt.Sprintf("%s: [%s] %s", location(f), h.Code, msg))
+		}
+	}
+
+	// Origins have no call site so they are attached to the oldest one, if any.
+	if len(t.Hops) > 0 && len(t.Hops[0].Frames) > 0 {
+		f := t.Hops[0].Frames[0]
+		for i := len(t.Origins) - 1; i >= 0; i-- {
+			o := strings.ReplaceAll(t.Origins[i], "\n", " ")
+			lines = append([]string{fmt.Sprintf("%s: %s", location(f), o)}, lines...)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
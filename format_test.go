@@ -0,0 +1,111 @@
+package sos_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bjaus/sos"
+	"github.com/google/go-cmp/cmp"
+)
+
+func formatted() (error, int, int) {
+	var err error = sos.New(sos.NOTFOUND).WithError(errors.New("boom")).WithMessage("missing thing")
+	line := sos.As(err).Operation().Line()
+	err = sos.Trace(err)
+	return err, line, sos.As(err).Operation().Line()
+}
+
+func TestFormatTrace(t *testing.T) {
+	err, first, second := formatted()
+	file := sos.As(err).Operation().File()
+	id := sos.As(err).ID()
+
+	t.Run("default is text", func(t *testing.T) {
+		want := fmt.Sprintf("id: %s\nboom\n[not found] missing thing\n\t%s:%d\n\t%s:%d", id, file, first, file, second)
+		if got := err.Error(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		if got := sos.FormatTrace(err, nil); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var got []struct {
+			Code    sos.Code `json:"code"`
+			Message string   `json:"message"`
+			Reason  string   `json:"reason"`
+			Frames  []struct {
+				File string `json:"file"`
+				Line int    `json:"line"`
+			} `json:"frames"`
+		}
+		if err := json.Unmarshal([]byte(sos.FormatTrace(err, sos.JSONFormatter{})), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Code != sos.NOTFOUND || got[0].Message != "missing thing" || got[0].Reason != string(sos.NOTFOUND) {
+			t.Fatalf("unexpected hops: %+v", got)
+		}
+		lines := []int{got[0].Frames[0].Line, got[0].Frames[1].Line}
+		if diff := cmp.Diff(lines, []int{first, second}); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("logfmt", func(t *testing.T) {
+		got := sos.FormatTrace(err, sos.LogfmtFormatter{})
+		want := fmt.Sprintf(`id=%s origin=boom hop0.code="not found" hop0.reason="not found" hop0.message="missing thing" hop0.frames=%s:%d,%s:%d`, id, file, first, file, second)
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		if strings.Contains(got, "\n") {
+			t.Error("logfmt output spans multiple lines")
+		}
+	})
+
+	t.Run("compiler", func(t *testing.T) {
+		got := sos.FormatTrace(err, sos.CompilerFormatter{})
+		want := strings.Join([]string{
+			fmt.Sprintf("%s:%d: boom", file, first),
+			fmt.Sprintf("%s:%d: [not found] missing thing", file, first),
+			fmt.Sprintf("%s:%d: [not found] missing thing", file, second),
+		}, "\n")
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("global", func(t *testing.T) {
+		prev := sos.DefaultTraceFormatter
+		defer func() { sos.DefaultTraceFormatter = prev }()
+
+		sos.DefaultTraceFormatter = sos.CompilerFormatter{}
+		if got, want := err.Error(), sos.FormatTrace(err, sos.CompilerFormatter{}); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+	t.Run("custom", func(t *testing.T) {
+		err := sos.Trace(sos.New(sos.INVALID).WithDetail("field", "email"))
+
+		var f details
+		sos.FormatTrace(err, &f)
+		if diff := cmp.Diff(f.got, []string{"field"}); diff != "" {
+			t.Error(diff)
+		}
+	})
+}
+
+// details is a custom TraceFormatter recording the detail keys it receives.
+type details struct{ got []string }
+
+func (f *details) Format(t sos.TraceInfo) string {
+	for _, h := range t.Hops {
+		for _, d := range h.Details {
+			f.got = append(f.got, d.Key)
+		}
+	}
+	return ""
+}
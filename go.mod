@@ -1,5 +1,5 @@
 module github.com/bjaus/sos
 
-go 1.17
+go 1.21
 
 require github.com/google/go-cmp v0.5.9
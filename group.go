@@ -0,0 +1,146 @@
+package sos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Precedence orders error codes from most to least significant. It is used to pick
+// the Code of an error made up of multiple causes such as the one returned by Group.
+//
+// Codes which are not listed are less significant than every listed Code.
+var Precedence = []Code{
+	INTERNAL,
+	NOTIMPLEMENTED,
+	TEMPORARY,
+	TIMEOUT,
+	UNAUTHORIZED,
+	FORBIDDEN,
+	CONFLICT,
+	EXPIRED,
+	UNPROCESSABLE,
+	INVALID,
+	NOTFOUND,
+}
+
+// Prevailing picks the most significant Code according to the precedence provided.
+//
+// If the precedence is nil then Precedence is used. If none of the codes are listed
+// in the precedence then the first Code is returned.
+func Prevailing(precedence []Code, codes ...Code) Code {
+	if precedence == nil {
+		precedence = Precedence
+	}
+
+	rank := func(c Code) int {
+		for i, p := range precedence {
+			if p == c {
+				return i
+			}
+		}
+		return len(precedence)
+	}
+
+	var best Code
+	for i, c := range codes {
+		if i == 0 || rank(c) < rank(best) {
+			best = c
+		}
+	}
+
+	return best
+}
+
+// Group runs functions in goroutines and aggregates every failure into a single error.
+//
+// Unlike golang.org/x/sync/errgroup every error is kept. Panics in the functions are
+// recovered into INTERNAL errors holding the stack of the goroutine which panicked.
+//
+// The zero value is ready to use and does not cancel on error.
+type Group struct {
+	// Precedence overrides the package Precedence when picking the aggregate Code.
+	Precedence []Code
+	// CancelOnError cancels the context created by GroupContext when the first error occurs.
+	CancelOnError bool
+
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	errs   []error
+	cancel context.CancelFunc
+}
+
+// GroupContext creates a Group and an associated context derived from the one
+// provided. The context is canceled when Wait returns or, when CancelOnError is
+// set, once the first error occurs.
+func GroupContext(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// Go calls the function provided in a new goroutine.
+func (g *Group) Go(fn func() error) {
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+
+		var err error
+		func() {
+			defer Recover(&err)
+			err = fn()
+		}()
+
+		if err == nil {
+			return
+		}
+
+		g.mu.Lock()
+		g.errs = append(g.errs, err)
+		first := len(g.errs) == 1
+		g.mu.Unlock()
+
+		if first && g.CancelOnError && g.cancel != nil {
+			g.cancel()
+		}
+	}()
+}
+
+// Wait blocks until every function has returned and produces the aggregate error.
+//
+// If no function failed the returned value is nil. A single failure is traced and
+// returned as is. Multiple failures are joined into one error whose Code is the
+// most significant Code of the failures according to the precedence. Failures
+// which do not satisfy the Error interface count as INTERNAL.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+
+	if g.cancel != nil {
+		g.cancel()
+	}
+
+	g.mu.Lock()
+	errs := make([]error, len(g.errs))
+	copy(errs, g.errs)
+	g.mu.Unlock()
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return traceErr(context.Background(), false, 0, errs[0])
+	}
+
+	codes := make([]Code, len(errs))
+	for i, err := range errs {
+		if codes[i] = Kind(err); codes[i] == "" {
+			codes[i] = INTERNAL
+		}
+	}
+
+	code := Prevailing(g.Precedence, codes...)
+	e := create(context.Background(), false, 1, code, fmt.Sprintf("%d errors occurred", len(errs)), errors.Join(errs...))
+
+	return e
+}
@@ -0,0 +1,88 @@
+package sos_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjaus/sos"
+)
+
+func TestGroup(t *testing.T) {
+
+	t.Run("success", func(t *testing.T) {
+		var g sos.Group
+		g.Go(func() error { return nil })
+		if err := g.Wait(); err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		var g sos.Group
+		g.Go(func() error { return sos.New(sos.NOTFOUND) })
+		g.Go(func() error { return sos.New(sos.TEMPORARY) })
+		g.Go(func() error { return sos.New(sos.NOTFOUND) })
+
+		err := g.Wait()
+		if got := sos.Kind(err); got != sos.TEMPORARY {
+			t.Errorf("code: got %q, want %q", got, sos.TEMPORARY)
+		}
+	})
+
+	t.Run("custom precedence", func(t *testing.T) {
+		g := sos.Group{Precedence: []sos.Code{sos.NOTFOUND, sos.TEMPORARY}}
+		g.Go(func() error { return sos.New(sos.TEMPORARY) })
+		g.Go(func() error { return sos.New(sos.NOTFOUND) })
+
+		if got := sos.Kind(g.Wait()); got != sos.NOTFOUND {
+			t.Errorf("code: got %q, want %q", got, sos.NOTFOUND)
+		}
+	})
+
+	t.Run("cancel on error", func(t *testing.T) {
+		g, ctx := sos.GroupContext(context.Background())
+		g.CancelOnError = true
+
+		g.Go(func() error { return errors.New("boom") })
+		g.Go(func() error {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Second):
+				return errors.New("context was not canceled")
+			}
+		})
+
+		err := g.Wait()
+		if got := sos.Kind(err); got != sos.INTERNAL {
+			t.Errorf("code: got %q, want %q", got, sos.INTERNAL)
+		}
+		if strings.Contains(err.Error(), "not canceled") {
+			t.Error(err)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		var g sos.Group
+		g.Go(func() error { panic("kaboom") })
+
+		err := g.Wait()
+		if got := sos.Kind(err); got != sos.INTERNAL {
+			t.Errorf("code: got %q, want %q", got, sos.INTERNAL)
+		}
+
+		var p *sos.PanicError
+		if !errors.As(err, &p) {
+			t.Fatalf("panic error should be in the chain: %v", err)
+		}
+		if p.Value != "kaboom" {
+			t.Errorf("value: got %v, want kaboom", p.Value)
+		}
+		if len(p.Stack) == 0 || p.Stack[0].Caller() != "TestGroup" {
+			t.Errorf("stack should start at the panicking function: %v", p.Stack)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package sos_test
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/bjaus/sos"
+)
+
+func notFound() *sos.Err {
+	sos.Helper()
+	return sos.New(sos.NOTFOUND)
+}
+
+func nested() *sos.Err {
+	sos.Helper()
+	return notFound()
+}
+
+func traced(err error) error {
+	sos.Helper()
+	return sos.Trace(err)
+}
+
+func skipped() *sos.Err {
+	return sos.NewSkip(1, sos.NOTFOUND)
+}
+
+func skipTraced(err error) error {
+	return sos.TraceSkip(1, err)
+}
+
+func line() int {
+	_, _, l, _ := runtime.Caller(1)
+	return l
+}
+
+func TestHelper(t *testing.T) {
+
+	cases := map[string]struct {
+		fn func() (error, int)
+	}{
+		"helper": {
+			fn: func() (error, int) { return notFound(), line() },
+		},
+		"nested helpers": {
+			fn: func() (error, int) { return nested(), line() },
+		},
+		"helper trace": {
+			fn: func() (error, int) { return traced(errors.New("boom")), line() },
+		},
+		"helper trace of Err": {
+			fn: func() (error, int) { return traced(sos.New(sos.NOTFOUND)), line() },
+		},
+		"NewSkip": {
+			fn: func() (error, int) { return skipped(), line() },
+		},
+		"TraceSkip": {
+			fn: func() (error, int) { return skipTraced(errors.New("boom")), line() },
+		},
+		"NewSkip zero": {
+			fn: func() (error, int) { return sos.NewSkip(0, sos.NOTFOUND), line() },
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err, want := tc.fn()
+			if got := sos.As(err).Operation().Line(); got != want {
+				t.Errorf("got line %d, want %d", got, want)
+			}
+		})
+	}
+
+	t.Run("recover", func(t *testing.T) {
+		var want int
+		err := func() (err error) {
+			defer sos.Recover(&err)
+			want = line() + 1
+			detonate()
+			return nil
+		}()
+		if got := sos.As(err).Operation().Line(); got != want {
+			t.Errorf("got line %d, want %d", got, want)
+		}
+	})
+}
+
+func detonate() {
+	sos.Helper()
+	panic("boom")
+}
@@ -0,0 +1,189 @@
+package sos
+
+// Transition is the state of an error at a single hop of its history.
+type Transition struct {
+	Code    Code
+	Reason  string
+	Message string
+	// Details are the details added or changed at this hop.
+	Details DetailList
+	// Op is the call site of the hop which is nil when it is unknown.
+	Op Op
+}
+
+// History produces the state of the error at every hop starting with the origin.
+//
+// A hop is recorded whenever the error is traced or wrapped and whenever its Code
+// is changed using WithCode. Messages and details are redacted according to
+// RedactRules.
+func (e *Err) History() []Transition {
+	if e == nil {
+		return nil
+	}
+
+	hops := hopsOf(e, true)
+	ts := make([]Transition, len(hops))
+	for i, h := range hops {
+		ts[i] = Transition{Code: h.code, Reason: h.reason, Message: h.message, Details: h.added, Op: h.op}
+	}
+	return ts
+}
+
+// snapshot is the state of an Err before its Code was changed in place.
+type snapshot struct {
+	code    Code
+	reason  string
+	message string
+	public  string
+	op      Op
+	attrs   []attr
+}
+
+// record stores the current state of the error as a snapshot.
+func (e *Err) record() {
+	s := snapshot{code: e.code, reason: e.reason, message: e.message, public: e.public}
+	if e.op != nil {
+		s.op = e.op
+	}
+	if len(e.attrs) > 0 {
+		s.attrs = append([]attr(nil), e.attrs...)
+	}
+	// Copies of the error made while tracing share the backing array.
+	e.history = append(e.history[:len(e.history):len(e.history)], s)
+}
+
+// hop is a single step of the history of an error.
+type hop struct {
+	code    Code
+	reason  string
+	message string
+	public  string
+	op      Op
+	// details are all the details held at this hop.
+	details DetailList
+	// added are the details added or changed at this hop.
+	added DetailList
+	// vis holds the visibility of the details which are not internal.
+	vis map[string]Audience
+}
+
+// hopsOf produces the hops of every Error in the chain starting with the origin.
+//
+// The details, added details and visibility of the hops are only collected when
+// details is true since redacting them is costly and the text trace omits them.
+func hopsOf(err error, details bool) []hop {
+	var xs []Error
+	Walk(err, func(_ int, w error) bool {
+		if x, ok := w.(Error); ok {
+			xs = append(xs, x)
+		}
+		return true
+	})
+	return hopsFrom(xs, details)
+}
+
+// hopsFrom produces the hops of the Error values provided in the order they were
+// found in the chain. See hopsOf.
+func hopsFrom(xs []Error, details bool) []hop {
+	var hops []hop
+
+	for _, x := range xs {
+		if x.Code() == "" {
+			continue
+		}
+
+		ops := operations(x)
+		if len(ops) == 0 {
+			ops = []Op{nil}
+		}
+
+		h := hop{
+			code:    x.Code(),
+			reason:  x.Reason(),
+			message: redactText(x.Message()),
+		}
+		if details {
+			h.details = detailsOf(x, AudienceInternal)
+		}
+		if e, ok := x.(*Err); ok {
+			h.public = e.public
+			if details {
+				h.vis = visibility(e.attrs)
+			}
+		}
+		for _, o := range ops {
+			h.op = o
+			hops = append(hops, h)
+		}
+
+		// Snapshots are older than the current state, most recent last.
+		if e, ok := x.(*Err); ok {
+			for i := len(e.history) - 1; i >= 0; i-- {
+				s := e.history[i]
+				h := hop{
+					code:    s.code,
+					reason:  s.reason,
+					message: redactText(s.message),
+					public:  s.public,
+					op:      s.op,
+				}
+				if details {
+					h.details = make(DetailList, len(s.attrs))
+					for j, a := range s.attrs {
+						h.details[j] = Detail{Key: a.key, Value: a.redacted()}
+					}
+					h.vis = visibility(s.attrs)
+				}
+				hops = append(hops, h)
+			}
+		}
+	}
+
+	// Order from the origin and determine the details added at each hop.
+	for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+		hops[i], hops[j] = hops[j], hops[i]
+	}
+
+	if !details {
+		return hops
+	}
+
+	var prev DetailList
+	for i := range hops {
+		hops[i].added = added(prev, hops[i].details)
+		prev = hops[i].details
+	}
+
+	return hops
+}
+
+// added produces the details of next which are not in prev or hold another value.
+func added(prev, next DetailList) DetailList {
+	old := make(map[string]string, len(prev))
+	for _, d := range prev {
+		old[d.Key] = stringify(d.Value)
+	}
+
+	var d DetailList
+	for _, x := range next {
+		if v, ok := old[x.Key]; !ok || v != stringify(x.Value) {
+			d = append(d, x)
+		}
+	}
+	return d
+}
+
+// visibility produces the visibility of the attributes which are not internal.
+func visibility(attrs []attr) map[string]Audience {
+	var m map[string]Audience
+	for _, a := range attrs {
+		if a.vis == AudienceInternal {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]Audience)
+		}
+		m[a.key] = a.vis
+	}
+	return m
+}
@@ -0,0 +1,126 @@
+package sos_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bjaus/sos"
+	"github.com/google/go-cmp/cmp"
+)
+
+func repository() error {
+	return sos.New(sos.NOTFOUND).
+		WithMessage("record missing").
+		WithDetail("table", "users")
+}
+
+func policy() error {
+	err := sos.Trace(repository())
+	return sos.As(err).
+		WithCode(sos.FORBIDDEN).
+		WithReason("POLICY_DENIED").
+		WithDetail("policy", "owner-only")
+}
+
+func TestHistory(t *testing.T) {
+	err := sos.As(sos.Trace(policy()))
+
+	type state struct {
+		Code    sos.Code
+		Reason  string
+		Message string
+		Details map[string]string
+		Op      bool
+	}
+
+	var got []state
+	for _, h := range err.History() {
+		got = append(got, state{Code: h.Code, Reason: h.Reason, Message: h.Message, Details: h.Details.Map(), Op: h.Op != nil})
+	}
+
+	want := []state{
+		{Code: sos.NOTFOUND, Reason: string(sos.NOTFOUND), Message: "record missing", Details: map[string]string{"table": "users"}, Op: true},
+		{Code: sos.NOTFOUND, Reason: string(sos.NOTFOUND), Message: "record missing", Details: map[string]string{}, Op: true},
+		{Code: sos.FORBIDDEN, Reason: "POLICY_DENIED", Message: "record missing", Details: map[string]string{"policy": "owner-only"}, Op: true},
+		{Code: sos.FORBIDDEN, Reason: "POLICY_DENIED", Message: "record missing", Details: map[string]string{}, Op: true},
+	}
+
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+
+	t.Run("trace", func(t *testing.T) {
+		s := err.Error()
+		if !strings.Contains(s, "[not found] record missing") || !strings.Contains(s, "[forbidden] record missing") {
+			t.Errorf("trace is missing the code transition: %q", s)
+		}
+		if strings.Index(s, "[not found]") > strings.Index(s, "[forbidden]") {
+			t.Errorf("trace is out of order: %q", s)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		b, e := json.Marshal(err)
+		if e != nil {
+			t.Fatal(e)
+		}
+
+		var v struct {
+			History []struct {
+				Code    sos.Code          `json:"code"`
+				Details map[string]string `json:"details"`
+			} `json:"history"`
+		}
+		if e := json.Unmarshal(b, &v); e != nil {
+			t.Fatal(e)
+		}
+
+		codes := make([]sos.Code, len(v.History))
+		for i, h := range v.History {
+			codes[i] = h.Code
+		}
+		if diff := cmp.Diff(codes, []sos.Code{sos.NOTFOUND, sos.NOTFOUND, sos.FORBIDDEN, sos.FORBIDDEN}); diff != "" {
+			t.Error(diff)
+		}
+		if got := v.History[2].Details["policy"]; got != "owner-only" {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("debug token", func(t *testing.T) {
+		kr := sos.NewKeyring()
+		if e := kr.Add("k", make([]byte, 32)); e != nil {
+			t.Fatal(e)
+		}
+		token, e := sos.Encrypt(kr, err)
+		if e != nil {
+			t.Fatal(e)
+		}
+		dec, e := sos.Decrypt(kr, token)
+		if e != nil {
+			t.Fatal(e)
+		}
+
+		var codes []sos.Code
+		for _, h := range dec.History() {
+			codes = append(codes, h.Code)
+		}
+		if diff := cmp.Diff(codes, []sos.Code{sos.NOTFOUND, sos.NOTFOUND, sos.FORBIDDEN, sos.FORBIDDEN}); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("wrapped details are kept", func(t *testing.T) {
+		inner := sos.New(sos.INVALID).WithDetail("field", "name")
+		outer := sos.As(sos.Trace(inner)).WithDetail("field", "email")
+
+		h := outer.History()
+		if got := h[0].Details.Map()["field"]; got != "name" {
+			t.Errorf("got %q, want %q", got, "name")
+		}
+		if got := h[1].Details.Map()["field"]; got != "email" {
+			t.Errorf("got %q, want %q", got, "email")
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package sos
+
+import (
+	"context"
+	"sync"
+)
+
+// Hook is called with an error at a specific point of its lifecycle.
+//
+// The context is the one associated with the operation when available and
+// context.Background otherwise. Hooks are called synchronously so they should be
+// fast and must not retain the error beyond the call since it may still be
+// modified by its builder methods.
+type Hook func(ctx context.Context, e *Err)
+
+type hookPoint int
+
+const (
+	hookCreate hookPoint = iota
+	hookTrace
+	hookRender
+	hookClassify
+	hookPoints
+)
+
+type hook struct {
+	id int
+	fn Hook
+}
+
+var hooks struct {
+	mu   sync.RWMutex
+	next int
+	fns  [hookPoints][]hook
+}
+
+// OnCreate registers a hook called whenever an error is created.
+//
+// The returned function unregisters the hook.
+func OnCreate(fn Hook) (remove func()) {
+	return register(hookCreate, fn)
+}
+
+// OnTrace registers a hook called whenever an error is traced.
+//
+// Custom Error implementations are passed to the hook as an Err value holding their
+// Code, reason, message and details. Changes made to it do not affect the original.
+//
+// The returned function unregisters the hook.
+func OnTrace(fn Hook) (remove func()) {
+	return register(hookTrace, fn)
+}
+
+// OnRender registers a hook called whenever an error is rendered. This includes
+// Problem, MarshalJSON, LogValue, CollectedProblems and the HTTPRenderer methods.
+//
+// The context is the one of the request when rendered using HTTPRenderer.RenderContext,
+// HTTPRenderer.RenderBatchContext or CollectedProblems.
+//
+// The returned function unregisters the hook.
+func OnRender(fn Hook) (remove func()) {
+	return register(hookRender, fn)
+}
+
+// OnClassify registers a hook called whenever an error is assigned a Code other
+// than the one it was created with. This includes foreign errors being classified
+// as INTERNAL by Trace and code changes made with WithCode.
+//
+// Hooks may reclassify the error using WithCode without triggering classify hooks again.
+//
+// The returned function unregisters the hook.
+func OnClassify(fn Hook) (remove func()) {
+	return register(hookClassify, fn)
+}
+
+// Observe registers a function which is called whenever an error is created.
+//
+// It is shorthand for OnCreate when the context is not needed.
+func Observe(fn func(e *Err)) (remove func()) {
+	return OnCreate(func(_ context.Context, e *Err) {
+		fn(e)
+	})
+}
+
+// HookScope registers hooks which are removed when the scope is cleaned up.
+type HookScope struct {
+	c interface{ Cleanup(func()) }
+}
+
+// ScopeHooks creates a HookScope tied to the cleanup of the value provided
+// (i.e., *testing.T) so that hooks registered in a test don't leak into others.
+func ScopeHooks(c interface{ Cleanup(func()) }) HookScope {
+	return HookScope{c: c}
+}
+
+// OnCreate registers a hook for the lifetime of the scope. See OnCreate.
+func (s HookScope) OnCreate(fn Hook) {
+	s.c.Cleanup(OnCreate(fn))
+}
+
+// OnTrace registers a hook for the lifetime of the scope. See OnTrace.
+func (s HookScope) OnTrace(fn Hook) {
+	s.c.Cleanup(OnTrace(fn))
+}
+
+// OnRender registers a hook for the lifetime of the scope. See OnRender.
+func (s HookScope) OnRender(fn Hook) {
+	s.c.Cleanup(OnRender(fn))
+}
+
+// OnClassify registers a hook for the lifetime of the scope. See OnClassify.
+func (s HookScope) OnClassify(fn Hook) {
+	s.c.Cleanup(OnClassify(fn))
+}
+
+func register(p hookPoint, fn Hook) func() {
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+
+	id := hooks.next
+	hooks.next++
+
+	// Copy on write so that running hooks never observe a partial update.
+	fns := make([]hook, len(hooks.fns[p]), len(hooks.fns[p])+1)
+	copy(fns, hooks.fns[p])
+	hooks.fns[p] = append(fns, hook{id: id, fn: fn})
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			hooks.mu.Lock()
+			defer hooks.mu.Unlock()
+
+			fns := make([]hook, 0, len(hooks.fns[p]))
+			for _, h := range hooks.fns[p] {
+				if h.id != id {
+					fns = append(fns, h)
+				}
+			}
+			hooks.fns[p] = fns
+		})
+	}
+}
+
+// fireError fires the hooks for an Error which is not an Err value. The conversion
+// only happens when hooks are registered.
+func fireError(p hookPoint, ctx context.Context, x Error) {
+	hooks.mu.RLock()
+	n := len(hooks.fns[p])
+	hooks.mu.RUnlock()
+
+	if n > 0 {
+		fire(p, ctx, convert(x))
+	}
+}
+
+func fire(p hookPoint, ctx context.Context, e *Err) {
+	hooks.mu.RLock()
+	fns := hooks.fns[p]
+	hooks.mu.RUnlock()
+
+	for _, h := range fns {
+		h.fn(ctx, e)
+	}
+}
@@ -0,0 +1,144 @@
+package sos_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/bjaus/sos"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHooks(t *testing.T) {
+
+	var events []string
+
+	record := func(name string) sos.Hook {
+		return func(_ context.Context, e *sos.Err) {
+			events = append(events, name+":"+string(e.Code()))
+		}
+	}
+
+	t.Run("scoped", func(t *testing.T) {
+		scope := sos.ScopeHooks(t)
+		scope.OnCreate(record("create"))
+		scope.OnTrace(record("trace"))
+		scope.OnRender(record("render"))
+		scope.OnClassify(record("classify"))
+
+		err := sos.Trace(sos.New(sos.NOTFOUND))
+		err = sos.Must(err).WithCode(sos.FORBIDDEN)
+		_, _ = json.Marshal(err)
+		_ = sos.Trace(errors.New("foreign"))
+	})
+
+	// Hooks registered in the scope above must not leak.
+	sos.New(sos.INVALID)
+
+	want := []string{
+		"create:not found",
+		"trace:not found",
+		"classify:forbidden",
+		"render:forbidden",
+		"create:internal",
+		"classify:internal",
+	}
+	if diff := cmp.Diff(events, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestHooksRenderPaths(t *testing.T) {
+
+	type key struct{}
+
+	var got []string
+	sos.ScopeHooks(t).OnRender(func(ctx context.Context, e *sos.Err) {
+		v, _ := ctx.Value(key{}).(string)
+		got = append(got, string(e.Code())+":"+v)
+	})
+
+	ctx := context.WithValue(context.Background(), key{}, "req")
+	err := sos.New(sos.NOTFOUND)
+
+	var b sos.BatchResult
+	b.Record("a", sos.New(sos.INVALID))
+
+	_ = err.Problem(sos.AudiencePublic)
+	_ = err.LogValue()
+	sos.HTTPRenderer{}.RenderContext(ctx, httptest.NewRecorder(), err)
+	sos.HTTPRenderer{}.RenderBatchContext(ctx, httptest.NewRecorder(), &b)
+
+	cctx := sos.WithCollector(ctx)
+	sos.Collect(cctx, sos.New(sos.CONFLICT))
+	_ = sos.CollectedProblems(cctx, sos.AudiencePublic)
+
+	panicky := sos.RecoverMiddleware(sos.HTTPRenderer{}, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	panicky.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := []string{
+		"not found:",
+		"not found:",
+		"not found:req",
+		"invalid:req",
+		"conflict:req",
+		"internal:req",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestHooksTraceCustom(t *testing.T) {
+
+	var got []string
+	sos.ScopeHooks(t).OnTrace(func(_ context.Context, e *sos.Err) {
+		got = append(got, string(e.Code()))
+	})
+
+	_ = sos.Trace(&legacy{})
+	_ = sos.Trace(&traceable{})
+
+	if diff := cmp.Diff(got, []string{string(sos.CONFLICT), string(sos.CONFLICT)}); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestHooksReclassify(t *testing.T) {
+
+	sos.ScopeHooks(t).OnClassify(func(_ context.Context, e *sos.Err) {
+		if errors.Is(e.Unwrap(), context.DeadlineExceeded) {
+			e.WithCode(sos.TIMEOUT)
+		}
+	})
+
+	err := sos.Trace(context.DeadlineExceeded)
+	if got := sos.Kind(err); got != sos.TIMEOUT {
+		t.Errorf("code: got %q, want %q", got, sos.TIMEOUT)
+	}
+}
+
+func TestHooksConcurrent(t *testing.T) {
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			remove := sos.OnCreate(func(context.Context, *sos.Err) {})
+			remove()
+		}()
+		go func() {
+			defer wg.Done()
+			sos.New(sos.TEMPORARY)
+		}()
+	}
+	wg.Wait()
+}
@@ -1,6 +1,7 @@
 package sos
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 )
@@ -20,22 +21,147 @@ var HTTPStatusMap = map[Code]int{
 	UNPROCESSABLE:  http.StatusUnprocessableEntity,
 }
 
+// HTTPStatus maps the Code provided to an HTTP status code using HTTPStatusMap.
+//
+// Codes without a mapping are treated as internal server errors.
+func HTTPStatus(code Code) int {
+	if status, ok := HTTPStatusMap[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// ProblemContentType is the media type for RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details representation of an error.
+type Problem struct {
+	Type     string     `json:"type,omitempty"`
+	Title    string     `json:"title"`
+	Status   int        `json:"status"`
+	Detail   string     `json:"detail,omitempty"`
+	Instance string     `json:"instance,omitempty"`
+	Code     Code       `json:"code"`
+	Reason   string     `json:"reason,omitempty"`
+	Details  DetailList `json:"details,omitempty"`
+	Trace    string     `json:"trace,omitempty"`
+	Debug    string     `json:"debug,omitempty"`
+}
+
+// Problem produces the problem details of the error for the audience provided.
+//
+// Messages, details and the trace are filtered according to the audience so that
+// internal information is never included for public or partner audiences.
+func (e *Err) Problem(a Audience) Problem {
+	return e.problem(context.Background(), a)
+}
+
+// problem implements Problem firing the render hooks with the context provided.
+func (e *Err) problem(ctx context.Context, a Audience) Problem {
+	fire(hookRender, ctx, e)
+
+	p := Problem{
+		Title:    string(e.code),
+		Status:   HTTPStatus(e.code),
+		Detail:   redactText(e.MessageFor(a)),
+		Instance: e.id,
+		Code:     e.code,
+	}
+
+	if a.allows(AudienceInternal) {
+		p.Reason = e.reason
+		p.Trace = e.TraceFor(a)
+	} else if e.reason != string(e.code) {
+		p.Reason = e.reason
+	}
+
+	if d := e.OrderedDetails(a); len(d) > 0 {
+		p.Details = d
+	}
+
+	return p
+}
+
+// HTTPRenderer writes errors as RFC 7807 problem details.
+//
+// The zero value renders for the public audience.
+type HTTPRenderer struct {
+	// Audience determines which messages and details are included.
+	Audience Audience
+	// Debug, when provided, is used to include an encrypted debug token holding
+	// the full trace so that support engineers can inspect it using Decrypt.
+	Debug *Keyring
+}
+
+// Render writes the error provided to the response writer.
+//
+// Errors which do not satisfy the Error interface are rendered as INTERNAL errors
+// without exposing the original error message to non-internal audiences. Nothing is
+// written when the error is nil.
+func (r HTTPRenderer) Render(w http.ResponseWriter, err error) {
+	r.RenderContext(context.Background(), w, err)
+}
+
+// RenderContext writes the error provided to the response writer like Render where
+// the context, typically the one of the request, is passed to the hooks.
+func (r HTTPRenderer) RenderContext(ctx context.Context, w http.ResponseWriter, err error) {
+	if err == nil {
+		return
+	}
+
+	e := As(err)
+	if e == nil {
+		e = create(ctx, false, 1, INTERNAL, err.Error(), err)
+		e.classify(ctx)
+	}
+
+	p := e.problem(ctx, r.Audience)
+
+	if r.Debug != nil {
+		if token, err := Encrypt(r.Debug, e); err == nil {
+			p.Debug = token
+		}
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
 // MarshalJSON implements the json.Marshaler interface.
+//
+// The output is intended for the internal audience. Use Problem or HTTPRenderer
+// when rendering errors for clients.
 func (e *Err) MarshalJSON() ([]byte, error) {
 	if e == nil {
 		return json.Marshal(nil)
 	}
 
+	fire(hookRender, context.Background(), e)
+
 	v := struct {
-		Code    Code              `json:"code"`
-		Message string            `json:"message"`
-		Reason  string            `json:"reason"`
-		Details map[string]string `json:"details"`
+		ID          string     `json:"id,omitempty"`
+		Code        Code       `json:"code"`
+		Message     string     `json:"message"`
+		Reason      string     `json:"reason"`
+		Details     DetailList `json:"details"`
+		Fingerprint string     `json:"fingerprint"`
+		History     []jsonHop  `json:"history"`
 	}{
-		Code:    e.Code(),
-		Message: e.Message(),
-		Reason:  e.Reason(),
-		Details: e.Details(),
+		ID:          e.ID(),
+		Code:        e.Code(),
+		Message:     redactText(e.Message()),
+		Reason:      e.Reason(),
+		Details:     e.OrderedDetails(AudienceInternal),
+		Fingerprint: Fingerprint(e),
+	}
+
+	for _, t := range e.History() {
+		h := jsonHop{Code: t.Code, Message: t.Message, Reason: t.Reason, Details: t.Details, Frames: []jsonFrame{}}
+		if o := t.Op; o != nil {
+			h.Frames = append(h.Frames, jsonFrame{Package: o.Package(), Caller: o.Caller(), File: TrimPaths.Trim(o.File()), Line: o.Line()})
+		}
+		v.History = append(v.History, h)
 	}
 
 	return json.Marshal(v)
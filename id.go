@@ -0,0 +1,56 @@
+package sos
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+)
+
+// IDGenerator produces the unique instance ID assigned to every error when it is created.
+//
+// It defaults to NewULID and may be replaced, for example with a deterministic
+// generator in tests. Setting it to nil disables instance IDs.
+var IDGenerator = NewULID
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID produces a lexicographically sortable 26 character ULID made up of a
+// 48 bit millisecond timestamp followed by 80 bits of randomness.
+func NewULID() string {
+	var b [16]byte
+
+	ms := uint64(time.Now().UnixMilli())
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(err) // crypto/rand never fails on supported platforms.
+	}
+
+	return encodeULID(b)
+}
+
+func encodeULID(b [16]byte) string {
+	var s [26]byte
+
+	// 128 bits are encoded as 26 base32 characters (130 bits) with the two
+	// leading bits always zero.
+	hi := binary.BigEndian.Uint64(b[0:8])
+	lo := binary.BigEndian.Uint64(b[8:16])
+
+	for i := 25; i >= 0; i-- {
+		s[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(s[:])
+}
+
+func newID() string {
+	if IDGenerator == nil {
+		return ""
+	}
+	return IDGenerator()
+}
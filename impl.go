@@ -1,5 +1,11 @@
 package sos
 
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
 var (
 	_ Error = new(Err)
 )
@@ -9,12 +15,27 @@ var (
 // The values are encapsulated in order to accurately obtain the
 // runtime caller value and should be used an as builder.
 type Err struct {
+	id      string
 	reason  string
 	code    Code
 	message string
+	public  string
 	err     error
 	op      *op
-	detail  map[string]string
+	attrs   []attr
+	// history holds the prior states of the error when its Code is changed in place.
+	history []snapshot
+
+	// classifying guards against classify hooks triggering themselves.
+	classifying bool
+}
+
+// ID exposes the unique instance ID assigned to the error when it was created.
+//
+// The ID is kept when the error is traced or wrapped by another error using WithError
+// so that it can be quoted by clients and matched against logs.
+func (e *Err) ID() string {
+	return e.id
 }
 
 // Code exposes the error Code value.
@@ -22,18 +43,69 @@ func (e *Err) Code() Code {
 	return e.code
 }
 
-// Details exposes the error details map.
+// Details exposes the string view of the error details.
+//
+// The map is a copy so changes made to it do not affect the error; use WithDetail
+// and its variants instead. Use Detail and its typed variants to access the original
+// detail values.
 func (e *Err) Details() map[string]string {
-	return e.detail
+	m := make(map[string]string, len(e.attrs))
+	for _, x := range e.attrs {
+		m[x.key] = stringify(x.val)
+	}
+	return m
 }
 
 // Message exposes the most recent error message.
+//
+// The message is considered internal and may include text copied from wrapped errors.
 func (e *Err) Message() string {
 	return e.message
 }
 
+// PublicMessage exposes the message which is safe to show to any audience.
+//
+// If no public message has been set the FallbackMessage for the error Code is returned.
+func (e *Err) PublicMessage() string {
+	if e.public == "" {
+		return FallbackMessage(e.code)
+	}
+	return e.public
+}
+
+// MessageFor exposes the error message appropriate for the audience provided.
+func (e *Err) MessageFor(a Audience) string {
+	if a.allows(AudienceInternal) {
+		return e.Message()
+	}
+	return e.PublicMessage()
+}
+
+// DetailsFor exposes the string view of the error details visible to the audience provided.
+//
+// Details are internal unless added with WithPublicDetail or WithPartnerDetail.
+// Sensitive values are redacted according to RedactRules regardless of the audience.
+func (e *Err) DetailsFor(a Audience) map[string]string {
+	return e.OrderedDetails(a).Map()
+}
+
+// OrderedDetails exposes the typed error details visible to the audience provided
+// in the order they were added. See DetailsFor.
+func (e *Err) OrderedDetails(a Audience) DetailList {
+	d := make(DetailList, 0, len(e.attrs))
+	for _, x := range e.attrs {
+		if a.allows(x.vis) {
+			d = append(d, Detail{Key: x.key, Value: x.redacted()})
+		}
+	}
+	return d
+}
+
 // Operation exposes the most recent error Op value.
 func (e *Err) Operation() Op {
+	if e.op == nil {
+		return nil
+	}
 	return e.op
 }
 
@@ -47,12 +119,52 @@ func (e *Err) Error() string {
 	return trace(e)
 }
 
+// TraceFor produces the error trace appropriate for the audience provided.
+//
+// Only the internal audience receives the full trace with file paths and wrapped
+// error messages. Every other audience receives the code and public message.
+func (e *Err) TraceFor(a Audience) string {
+	if a.allows(AudienceInternal) {
+		return trace(e)
+	}
+	return fmt.Sprintf("[%s] %s", e.code, redactText(e.PublicMessage()))
+}
+
+// Unwrap exposes the wrapped error.
 func (e *Err) Unwrap() error {
+	if e == nil {
+		return nil
+	}
 	return e.err
 }
 
+// Is reports whether the error matches the target which allows the Code and reason
+// of the error to be matched using errors.Is. A Code target matches the Code of the
+// error and a Reason target matches its reason. Errors without a reason, whose reason
+// defaults to their Code, never match a Reason target.
+//
+// Since errors.Is inspects the whole chain, Codes of wrapped errors match as well.
+func (e *Err) Is(target error) bool {
+	if e == nil {
+		return false
+	}
+	switch t := target.(type) {
+	case Code:
+		return e.code == t
+	case Reason:
+		return e.reason != string(e.code) && e.reason == string(t)
+	}
+	return false
+}
+
 // WithCode changes the error Code of the error value.
+//
+// The state of the error prior to the change is kept in its History.
 func (e *Err) WithCode(code Code) *Err {
+	changed := e.code != code
+	if changed {
+		e.record()
+	}
 	if e.message == FallbackMessage(e.code) {
 		e.message = FallbackMessage(code)
 	}
@@ -60,40 +172,106 @@ func (e *Err) WithCode(code Code) *Err {
 		e.reason = string(code)
 	}
 	e.code = code
+	if changed {
+		e.classify(context.Background())
+	}
 	return e
 }
 
 // WithError adds an error value to the error chain.
 func (e *Err) WithError(err error) *Err {
-	if v := As(err); v != nil {
+	if v, ok := find(err).(*Err); ok {
+		if v.id != "" {
+			e.id = v.id
+		}
 		return e.propagate(v.err)
 	}
 	return e.propagate(err)
 }
 
-// WithMessage adds an error message.
+// WithMessage adds an internal error message.
 func (e *Err) WithMessage(msg string, args ...interface{}) *Err {
 	return e.propagate(message(sprintf(msg, args...)))
 }
 
+// WithInternalMessage adds an internal error message.
+//
+// It behaves exactly like WithMessage but makes the intended audience explicit.
+func (e *Err) WithInternalMessage(msg string, args ...interface{}) *Err {
+	return e.propagate(message(sprintf(msg, args...)))
+}
+
+// WithPublicMessage adds an error message which is safe to show to any audience.
+func (e *Err) WithPublicMessage(msg string, args ...interface{}) *Err {
+	return e.propagate(publicMessage(sprintf(msg, args...)))
+}
+
 // WithReason adds an error reason code.
 func (e *Err) WithReason(r string) *Err {
 	return e.propagate(reason(r))
 }
 
-// WithDetail adds a single key-value error detail to the error details map.
+// WithDetail adds a single key-value error detail.
 func (e *Err) WithDetail(k string, v string) *Err {
-	return e.propagate(details{k: v})
+	return e.propagate(attr{key: k, val: v, vis: AudienceInternal})
 }
 
-// WithDetails ...
+// WithDetailInt adds a single key-value error detail holding an integer.
+func (e *Err) WithDetailInt(k string, v int64) *Err {
+	return e.propagate(attr{key: k, val: v, vis: AudienceInternal})
+}
+
+// WithDetailFloat adds a single key-value error detail holding a number.
+func (e *Err) WithDetailFloat(k string, v float64) *Err {
+	return e.propagate(attr{key: k, val: v, vis: AudienceInternal})
+}
+
+// WithDetailBool adds a single key-value error detail holding a boolean.
+func (e *Err) WithDetailBool(k string, v bool) *Err {
+	return e.propagate(attr{key: k, val: v, vis: AudienceInternal})
+}
+
+// WithDetailDuration adds a single key-value error detail holding a duration.
+func (e *Err) WithDetailDuration(k string, v time.Duration) *Err {
+	return e.propagate(attr{key: k, val: v, vis: AudienceInternal})
+}
+
+// WithDetailTime adds a single key-value error detail holding a timestamp.
+func (e *Err) WithDetailTime(k string, v time.Time) *Err {
+	return e.propagate(attr{key: k, val: v, vis: AudienceInternal})
+}
+
+// WithDetailAny adds a single key-value error detail holding any value such as
+// nested objects. The value should be JSON serializable.
+func (e *Err) WithDetailAny(k string, v interface{}) *Err {
+	return e.propagate(attr{key: k, val: v, vis: AudienceInternal})
+}
+
+// WithDetails adds multiple key-value error details in the order of their keys.
 func (e *Err) WithDetails(d map[string]string) *Err {
-	return e.propagate(details(d))
+	return e.propagate(attrsOf(d))
+}
+
+// WithSensitiveDetail adds a single key-value error detail which is always redacted when rendered.
+//
+// The raw value remains available through Details.
+func (e *Err) WithSensitiveDetail(k string, v string) *Err {
+	return e.propagate(attr{key: k, val: v, vis: AudienceInternal, sensitive: true})
 }
 
-// WithResetDetails empties the error detail map.
+// WithPublicDetail adds a single key-value error detail which is visible to any audience.
+func (e *Err) WithPublicDetail(k string, v string) *Err {
+	return e.propagate(attr{key: k, val: v, vis: AudiencePublic})
+}
+
+// WithPartnerDetail adds a single key-value error detail which is visible to partners.
+func (e *Err) WithPartnerDetail(k string, v string) *Err {
+	return e.propagate(attr{key: k, val: v, vis: AudiencePartner})
+}
+
+// WithResetDetails removes every error detail.
 func (e *Err) WithResetDetails() *Err {
-	e.detail = make(map[string]string)
+	e.attrs = nil
 	return e
 }
 
@@ -104,39 +282,13 @@ func (e *Err) WithResetReason() *Err {
 }
 
 type (
-	message string
-	reason  string
-	details map[string]string
+	message       string
+	publicMessage string
+	reason        string
 )
 
 func (e *Err) propagate(args ...interface{}) *Err {
 
-	if e.err != nil {
-		p, ok := e.err.(*Err)
-		if ok {
-
-			for k, v := range p.detail {
-				if _, ok := e.detail[k]; !ok {
-					e.detail[k] = v
-				}
-			}
-
-			if p.reason != "" {
-				if p.code == e.code && e.reason == "" {
-					e.reason = p.reason
-				}
-			}
-		}
-
-		if e.message == FallbackMessage(e.code) {
-			if ok {
-				e.message = p.message
-			} else {
-				e.message = e.err.Error()
-			}
-		}
-	}
-
 	for i := range args {
 		switch v := args[i].(type) {
 		case nil:
@@ -147,29 +299,82 @@ func (e *Err) propagate(args ...interface{}) *Err {
 			e.reason = string(v)
 		case message:
 			e.message = string(v)
-		case details:
-			if len(e.detail) == 0 {
-				e.detail = v
-			} else {
-				for k, v := range v {
-					e.detail[k] = v
-				}
+		case publicMessage:
+			e.public = string(v)
+		case attr:
+			e.set(v)
+		case []attr:
+			for _, x := range v {
+				e.set(x)
 			}
 		case Err:
 			e.err = &v
+			e.adopt(&v)
 		case *Err:
 			if v == nil {
 				continue
 			}
 			cp := *v
+			if len(v.attrs) > 0 {
+				// Keep the details of the wrapped hop from changing along with the outer one.
+				cp.attrs = append([]attr(nil), v.attrs...)
+			}
 			e.err = &cp
+			if v.id != "" {
+				e.id = v.id
+			}
+			e.adopt(&cp)
 		case error:
 			e.err = v
 			if !Is(v) && e.message == FallbackMessage(e.code) {
 				e.message = v.Error()
 			}
+			if x, ok := v.(Error); ok {
+				e.adopt(x)
+			}
+		}
+	}
+
+	if e.err != nil && e.message == FallbackMessage(e.code) {
+		if p, ok := e.err.(Error); ok {
+			e.message = p.Message()
+		} else {
+			e.message = e.err.Error()
 		}
 	}
 
 	return e
 }
+
+// adopt copies the details, public message and reason of the Error attached as the
+// cause which have not been set on the error. It only runs when the cause is attached
+// so that later changes, such as WithResetReason, are not undone.
+func (e *Err) adopt(p Error) {
+	if x, ok := p.(*Err); ok {
+		for _, a := range x.attrs {
+			e.setDefault(a)
+		}
+		if e.public == "" {
+			e.public = x.public
+		}
+	} else {
+		for _, a := range attrsOf(p.Details()) {
+			e.setDefault(a)
+		}
+	}
+
+	// Adopt the wrapped reason when it describes the same Code and no reason has been set.
+	if r := p.Reason(); r != "" && p.Code() == e.code && e.reason == "" {
+		e.reason = r
+	}
+}
+
+func (e *Err) classify(ctx context.Context) {
+	if e.classifying {
+		return
+	}
+	e.classifying = true
+	defer func() { e.classifying = false }()
+
+	fire(hookClassify, ctx, e)
+}
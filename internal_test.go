@@ -81,3 +81,25 @@ func TestSprintf(t *testing.T) {
 		})
 	}
 }
+
+func TestStandard(t *testing.T) {
+
+	cases := map[string]bool{
+		"sort.Slice":                      true,
+		"net/http.(*Server).Serve":        true,
+		"runtime.goexit":                  true,
+		"main.main":                       false,
+		"github.com/bjaus/sos.New":        false,
+		"github.com/bjaus/sos_test.TestX": false,
+		"example.com/app/internal.Run":    false,
+		"":                                false,
+	}
+
+	for name, want := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := standard(importPath(name)); got != want {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
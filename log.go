@@ -0,0 +1,43 @@
+package sos
+
+import (
+	"context"
+	"log/slog"
+)
+
+var (
+	_ slog.LogValuer = new(Err)
+)
+
+// LogValue implements the slog.LogValuer interface.
+//
+// The value is intended for the internal audience and has sensitive details redacted.
+func (e *Err) LogValue() slog.Value {
+	if e == nil {
+		return slog.Value{}
+	}
+
+	fire(hookRender, context.Background(), e)
+
+	attrs := []slog.Attr{
+		slog.String("id", e.id),
+		slog.String("code", string(e.code)),
+		slog.String("reason", e.reason),
+		slog.String("message", redactText(e.message)),
+		slog.String("fingerprint", Fingerprint(e)),
+	}
+
+	if e.op != nil {
+		attrs = append(attrs, slog.String("op", e.op.String()))
+	}
+
+	if d := e.OrderedDetails(AudienceInternal); len(d) > 0 {
+		group := make([]any, len(d))
+		for i, x := range d {
+			group[i] = slog.Any(x.Key, x.Value)
+		}
+		attrs = append(attrs, slog.Group("details", group...))
+	}
+
+	return slog.GroupValue(attrs...)
+}
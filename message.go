@@ -7,7 +7,7 @@ import (
 
 // FallbackMessage creates a simple error message as the default error message.
 func FallbackMessage(code Code) string {
-	return fmt.Sprintf("%s error", code)
+	return string(code) + " error"
 }
 
 var (
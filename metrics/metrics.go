@@ -0,0 +1,147 @@
+// Package metrics counts sos errors and exposes them in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bjaus/sos"
+)
+
+// Overflow is the label value used once a Counter reaches its series limit.
+const Overflow = "other"
+
+// DefaultMaxSeries is the series limit used when Counter.MaxSeries is not set.
+const DefaultMaxSeries = 1000
+
+// Counter counts errors by code, reason, package and caller.
+//
+// Label cardinality is bounded by MaxSeries. Once the limit is reached errors
+// for new label combinations are counted with every label other than the code
+// set to Overflow.
+type Counter struct {
+	// Name is the metric name which defaults to "sos_errors_total".
+	Name string
+	// MaxSeries is the maximum number of label combinations which defaults to DefaultMaxSeries.
+	MaxSeries int
+
+	mu     sync.Mutex
+	series map[labels]uint64
+}
+
+var (
+	_ http.Handler = new(Counter)
+)
+
+type labels struct {
+	code   sos.Code
+	reason string
+	pkg    string
+	caller string
+}
+
+// New creates a Counter with default settings.
+func New() *Counter {
+	return &Counter{}
+}
+
+// Register starts counting every error created by the sos package and returns
+// a function which stops counting.
+//
+// Errors are counted when they are created so the reason is usually the default
+// for the code. Use Observe at the point errors are handled to count the final
+// code and reason instead.
+func (c *Counter) Register() (unregister func()) {
+	return sos.Observe(func(e *sos.Err) {
+		c.add(e)
+	})
+}
+
+// Observe counts the error provided.
+//
+// Errors which do not satisfy the sos.Error interface are counted as INTERNAL
+// and nil errors are ignored.
+func (c *Counter) Observe(err error) {
+	if err == nil {
+		return
+	}
+	if e := sos.As(err); e != nil {
+		c.add(e)
+		return
+	}
+	c.inc(labels{code: sos.INTERNAL, reason: string(sos.INTERNAL)})
+}
+
+func (c *Counter) add(e *sos.Err) {
+	l := labels{code: e.Code(), reason: e.Reason()}
+	if op := e.Operation(); op != nil {
+		l.pkg = op.Package()
+		l.caller = op.Caller()
+	}
+	c.inc(l)
+}
+
+func (c *Counter) inc(l labels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.series == nil {
+		c.series = make(map[labels]uint64)
+	}
+
+	max := c.MaxSeries
+	if max <= 0 {
+		max = DefaultMaxSeries
+	}
+
+	if _, ok := c.series[l]; !ok && len(c.series) >= max {
+		l = labels{code: l.code, reason: Overflow, pkg: Overflow, caller: Overflow}
+	}
+
+	c.series[l]++
+}
+
+// ServeHTTP implements the http.Handler interface by writing the counts in the
+// Prometheus text exposition format.
+func (c *Counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprint(w, c.String())
+}
+
+// String produces the counts in the Prometheus text exposition format.
+func (c *Counter) String() string {
+	name := c.Name
+	if name == "" {
+		name = "sos_errors_total"
+	}
+
+	c.mu.Lock()
+	lines := make([]string, 0, len(c.series))
+	for l, n := range c.series {
+		lines = append(lines, fmt.Sprintf(
+			"%s{code=\"%s\",reason=\"%s\",package=\"%s\",caller=\"%s\"} %d",
+			name, escape(string(l.code)), escape(l.reason), escape(l.pkg), escape(l.caller), n,
+		))
+	}
+	c.mu.Unlock()
+
+	sort.Strings(lines)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s Number of errors by code, reason and operation.\n", name)
+	fmt.Fprintf(&b, "# TYPE %s counter\n", name)
+	for _, line := range lines {
+		fmt.Fprintln(&b, line)
+	}
+
+	return b.String()
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
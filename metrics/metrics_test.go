@@ -0,0 +1,69 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bjaus/sos"
+	"github.com/bjaus/sos/metrics"
+)
+
+func TestCounter(t *testing.T) {
+
+	c := metrics.New()
+	unregister := c.Register()
+
+	sos.New(sos.NOTFOUND)
+	sos.New(sos.NOTFOUND)
+	c.Observe(errors.New("boom"))
+
+	unregister()
+	sos.New(sos.NOTFOUND) // Should no longer be counted.
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(b)
+
+	want := []string{
+		"# TYPE sos_errors_total counter",
+		`sos_errors_total{code="not found",reason="not found",package="metrics_test",caller="TestCounter"} 2`,
+		`sos_errors_total{code="internal",reason="internal",package="",caller=""} 1`,
+	}
+	for _, line := range want {
+		if !strings.Contains(body, line) {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestCounterCardinality(t *testing.T) {
+
+	c := &metrics.Counter{MaxSeries: 2}
+
+	for _, reason := range []string{"a", "b", "c", "d"} {
+		c.Observe(sos.New(sos.INVALID).WithReason(reason))
+	}
+
+	out := c.String()
+	if n := strings.Count(out, "sos_errors_total{"); n != 3 {
+		t.Errorf("series: got %d, want 3 (limit plus overflow):\n%s", n, out)
+	}
+	if !strings.Contains(out, `reason="other",package="other",caller="other"} 2`) {
+		t.Errorf("overflow series missing:\n%s", out)
+	}
+}
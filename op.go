@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Op provides details regarding the operation where the error originates.
@@ -24,34 +26,80 @@ var (
 	_ Op = new(op)
 )
 
+// op records the program counter of a call site and resolves the symbols on first access.
 type op struct {
+	pc   uintptr
+	once sync.Once
+	// path is the import path of the package which is used to identify the
+	// standard library.
+	path string
 	pkg  string
 	fn   string
 	file string
 	line int
 }
 
-func (o op) Package() string {
+func (o *op) resolve() {
+	// Values created with the symbols already known do not hold a program counter.
+	if o.pc == 0 {
+		return
+	}
+	o.once.Do(func() {
+		f, _ := runtime.CallersFrames([]uintptr{o.pc}).Next()
+		o.path = importPath(f.Function)
+		o.pkg, o.fn, _ = parseFunc(f.Function)
+		o.file, o.line = f.File, f.Line
+		learnRoot(f.Function, f.File)
+	})
+}
+
+func (o *op) Package() string {
+	o.resolve()
 	return o.pkg
 }
 
-func (o op) Caller() string {
+func (o *op) Caller() string {
+	o.resolve()
 	return o.fn
 }
 
-func (o op) File() string {
+func (o *op) File() string {
+	o.resolve()
 	return o.file
 }
 
-func (o op) Line() int {
+func (o *op) Line() int {
+	o.resolve()
 	return o.line
 }
 
-func (o op) String() string {
+func (o *op) String() string {
+	o.resolve()
 	if o.file == "" {
 		return ""
 	}
-	return fmt.Sprintf("%s:%d", o.file, o.line)
+	return fmt.Sprintf("%s:%d", TrimPaths.Trim(o.file), o.line)
+}
+
+// CaptureCallers enables recording the Op where errors are created and traced.
+//
+// Disabling it removes the cost of walking the stack at the expense of traces
+// without call sites. It should be configured once during program initialization.
+var CaptureCallers = true
+
+// SkipCallers lists the Codes whose errors do not record an Op when they are created
+// or traced. It is handy for expected errors on hot paths such as NOTFOUND cache misses.
+//
+// It should be configured once during program initialization.
+var SkipCallers = map[Code]bool{}
+
+// capture produces the Op for an error with the Code provided where skip is the number
+// of stack frames to ascend. The value is nil when caller capture is disabled.
+func capture(code Code, skip int) *op {
+	if !CaptureCallers || SkipCallers[code] {
+		return nil
+	}
+	return opParser(skip + 1)
 }
 
 var opReplacer = *strings.NewReplacer(
@@ -66,16 +114,184 @@ func opParser(skip int) *op {
 	}
 	skip++ // Add one to skip this func.
 
-	pc, file, line, ok := runtime.Caller(skip)
+	if helperCount.Load() > 0 {
+		return helperParser(skip)
+	}
+
+	// Only the program counter is recorded; the symbols are resolved on first access.
+	var pcs [1]uintptr
+	if runtime.Callers(skip+1, pcs[:]) == 0 {
+		return nil
+	}
+
+	return &op{pc: pcs[0]}
+}
+
+// helperParser is the opParser variant used once helpers have been marked which
+// ascends past the frames of functions marked by Helper.
+//
+// Like opParser only the program counter of the call site is recorded. The frames
+// are only expanded when a helper has been inlined into its caller.
+func helperParser(skip int) *op {
+	var pcs [32]uintptr
+	// Add two to skip runtime.Callers and this func.
+	n := runtime.Callers(skip+2, pcs[:])
+
+	for _, pc := range pcs[:n] {
+		// The program counters are return addresses so step back into the call.
+		f := runtime.FuncForPC(pc - 1)
+		if f == nil {
+			continue
+		}
+		if !helper(f.Name()) {
+			return &op{pc: pc}
+		}
+		if o := inlined(pc); o != nil {
+			return o
+		}
+	}
+
+	return nil
+}
+
+// inlined produces the Op of the first frame which is not a helper among the frames
+// inlined at the program counter provided. The Op is shared by every error recorded
+// at the same program counter.
+func inlined(pc uintptr) *op {
+	helpersMu.RLock()
+	o, ok := inlinedOps[pc]
+	helpersMu.RUnlock()
+	if ok {
+		return o
+	}
+
+	frames := runtime.CallersFrames([]uintptr{pc})
+	for {
+		f, more := frames.Next()
+		if f.Function != "" && !helper(f.Function) {
+			o = newOp(f.Function, f.File, f.Line)
+			break
+		}
+		if !more {
+			break
+		}
+	}
+
+	helpersMu.Lock()
+	if inlinedOps == nil {
+		inlinedOps = make(map[uintptr]*op)
+	}
+	inlinedOps[pc] = o
+	helpersMu.Unlock()
+
+	return o
+}
+
+var (
+	helpersMu sync.RWMutex
+	// helpers is the set of function names marked by Helper.
+	helpers map[string]struct{}
+	// inlinedOps caches the Op of the program counters where helpers were inlined.
+	// It is reset whenever a helper is marked.
+	inlinedOps map[uintptr]*op
+	// helperCount allows skipping the set lookups when no helpers are marked.
+	helperCount atomic.Int32
+)
+
+// Helper marks the calling function as a helper. When an Op is recorded the frames
+// of helper functions are skipped so that the Op is the call site of the helper.
+//
+//	func notFound(id int) *sos.Err {
+//		sos.Helper()
+//		return sos.New(sos.NOTFOUND).WithMessage("record %d not found", id)
+//	}
+func Helper() {
+	var pcs [1]uintptr
+	if runtime.Callers(2, pcs[:]) == 0 {
+		return
+	}
+	f := runtime.FuncForPC(pcs[0] - 1)
+	if f == nil {
+		return
+	}
+
+	name := f.Name()
+	if helper(name) {
+		return
+	}
+
+	helpersMu.Lock()
+	defer helpersMu.Unlock()
+
+	if _, ok := helpers[name]; ok {
+		return
+	}
+	if helpers == nil {
+		helpers = make(map[string]struct{})
+	}
+	helpers[name] = struct{}{}
+	inlinedOps = nil
+	helperCount.Add(1)
+}
+
+func helper(name string) bool {
+	helpersMu.RLock()
+	_, ok := helpers[name]
+	helpersMu.RUnlock()
+	return ok
+}
+
+// panicStack produces an Op for every frame of the panicking goroutine starting at the
+// function which panicked. It must be called from within a deferred function.
+func panicStack() []Op {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var ops []Op
+	var panicking bool
+
+	for {
+		f, more := frames.Next()
+		if panicking && !helper(f.Function) {
+			if o := newOp(f.Function, f.File, f.Line); o != nil {
+				ops = append(ops, o)
+			}
+		} else if f.Function == "runtime.gopanic" {
+			panicking = true
+		}
+		if !more {
+			break
+		}
+	}
+
+	return ops
+}
+
+func newOp(name string, file string, line int) *op {
+	learnRoot(name, file)
+
+	pkg, fn, ok := parseFunc(name)
 	if !ok {
 		return nil
 	}
 
-	f := runtime.FuncForPC(pc)
-	parts := strings.Split(f.Name(), "/")
+	return &op{
+		path: importPath(name),
+		pkg:  pkg,
+		fn:   fn,
+		file: file,
+		line: line,
+	}
+}
+
+// parseFunc splits the fully qualified function name provided by the runtime into
+// the package name and function.
+func parseFunc(name string) (pkg, fn string, ok bool) {
+	parts := strings.Split(name, "/")
 
 	if len(parts) == 0 {
-		return nil
+		return "", "", false
 	}
 
 	// Clean up some of the cruft provided by the runtime package.
@@ -84,11 +300,9 @@ func opParser(skip int) *op {
 	caller = parts[0]
 	parts = strings.Split(caller, ".")
 
-	var pkg, fn string
-
 	switch len(parts) {
 	case 0:
-		return nil
+		return "", "", false
 	case 2:
 		fn = strings.Join(parts[1:], ".")
 		fallthrough
@@ -96,10 +310,5 @@ func opParser(skip int) *op {
 		pkg = parts[0]
 	}
 
-	return &op{
-		pkg:  pkg,
-		fn:   fn,
-		file: file,
-		line: line,
-	}
+	return pkg, fn, true
 }
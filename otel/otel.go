@@ -0,0 +1,89 @@
+// Package otel records sos errors on OpenTelemetry spans.
+package otel
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bjaus/sos"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys set on spans by Record.
+const (
+	ErrorTypeKey   = attribute.Key("error.type")
+	ReasonKey      = attribute.Key("sos.reason")
+	IDKey          = attribute.Key("sos.id")
+	FingerprintKey = attribute.Key("sos.fingerprint")
+	DetailPrefix   = "sos.detail."
+)
+
+// Record records the error on the span active in the context provided.
+func Record(ctx context.Context, err error) {
+	RecordSpan(trace.SpanFromContext(ctx), err)
+}
+
+// RecordSpan records the error on the span provided.
+//
+// Errors which do not satisfy the sos.Error interface are recorded as INTERNAL
+// errors. The span status is only set to Error for server faults (see ServerFault)
+// since client errors are the expected outcome of a correctly behaving server.
+func RecordSpan(span trace.Span, err error) {
+	if err == nil || !span.IsRecording() {
+		return
+	}
+
+	// Foreign errors are described without creating an sos error so that recording
+	// them has no side effects such as firing hooks.
+	code, reason, id, msg := sos.INTERNAL, string(sos.INTERNAL), "", err.Error()
+	var details map[string]string
+	if e := sos.As(err); e != nil {
+		code, reason, id, msg = e.Code(), e.Reason(), e.ID(), e.MessageFor(sos.AudienceInternal)
+		details = e.DetailsFor(sos.AudienceInternal)
+	}
+
+	attrs := []attribute.KeyValue{
+		ErrorTypeKey.String(string(code)),
+		ReasonKey.String(reason),
+		IDKey.String(id),
+		FingerprintKey.String(sos.Fingerprint(err)),
+	}
+	for k, v := range details {
+		attrs = append(attrs, attribute.String(DetailPrefix+k, v))
+	}
+	span.SetAttributes(attrs...)
+
+	span.AddEvent("exception", trace.WithAttributes(
+		attribute.String("exception.type", string(code)),
+		attribute.String("exception.message", msg),
+		attribute.String("exception.stacktrace", sos.FormatTrace(err, nil)),
+	))
+
+	if ServerFault(code) {
+		span.SetStatus(codes.Error, string(code))
+	}
+}
+
+// ServerFault reports whether the Code provided indicates a failure of the server
+// rather than the client, based on sos.HTTPStatus.
+func ServerFault(code sos.Code) bool {
+	return sos.HTTPStatus(code) >= http.StatusInternalServerError
+}
+
+// Extractor is a sos.Extractor which adds the trace and span IDs of the span
+// active in the context as the "trace_id" and "span_id" details.
+//
+// Register it using sos.RegisterExtractor so that sos.NewContext and
+// sos.TraceContext correlate errors with traces.
+func Extractor(ctx context.Context) map[string]string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return map[string]string{
+		"trace_id": sc.TraceID().String(),
+		"span_id":  sc.SpanID().String(),
+	}
+}
@@ -0,0 +1,100 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bjaus/sos"
+	sosotel "github.com/bjaus/sos/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRecord(t *testing.T) {
+
+	cases := map[string]struct {
+		err    error
+		code   sos.Code
+		status codes.Code
+	}{
+		"server fault": {
+			err:    sos.New(sos.INTERNAL).WithDetail("table", "users"),
+			code:   sos.INTERNAL,
+			status: codes.Error,
+		},
+		"client error": {
+			err:    sos.New(sos.NOTFOUND).WithDetail("table", "users"),
+			code:   sos.NOTFOUND,
+			status: codes.Unset,
+		},
+		"foreign error": {
+			err:    errors.New("boom"),
+			code:   sos.INTERNAL,
+			status: codes.Error,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var created int
+			remove := sos.Observe(func(*sos.Err) { created++ })
+			defer remove()
+
+			exp := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+			ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+			sosotel.Record(ctx, tc.err)
+			span.End()
+
+			if created != 0 {
+				t.Errorf("recording should not create errors: got %d", created)
+			}
+
+			spans := exp.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("spans: got %d, want 1", len(spans))
+			}
+			s := spans[0]
+
+			if s.Status.Code != tc.status {
+				t.Errorf("status: got %v, want %v", s.Status.Code, tc.status)
+			}
+
+			attrs := attribute.NewSet(s.Attributes...)
+			if v, _ := attrs.Value(sosotel.ErrorTypeKey); v.AsString() != string(tc.code) {
+				t.Errorf("error.type: got %q, want %q", v.AsString(), tc.code)
+			}
+
+			if len(s.Events) != 1 || s.Events[0].Name != "exception" {
+				t.Fatalf("events: got %v, want a single exception event", s.Events)
+			}
+			event := attribute.NewSet(s.Events[0].Attributes...)
+			if v, _ := event.Value("exception.stacktrace"); v.AsString() != tc.err.Error() && sos.Is(tc.err) {
+				t.Errorf("stacktrace: got %q, want %q", v.AsString(), tc.err.Error())
+			}
+		})
+	}
+}
+
+func TestExtractor(t *testing.T) {
+
+	t.Cleanup(sos.RegisterExtractor(sosotel.Extractor))
+
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+	defer span.End()
+
+	err := sos.NewContext(ctx, sos.NOTFOUND)
+
+	sc := span.SpanContext()
+	if got := err.Details()["trace_id"]; got != sc.TraceID().String() {
+		t.Errorf("trace_id: got %q, want %q", got, sc.TraceID())
+	}
+	if got := err.Details()["span_id"]; got != sc.SpanID().String() {
+		t.Errorf("span_id: got %q, want %q", got, sc.SpanID())
+	}
+}
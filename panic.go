@@ -0,0 +1,108 @@
+package sos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// PanicError holds a value recovered from a panic along with the stack of the
+// goroutine which panicked.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack of the panicking goroutine starting at the function which panicked.
+	Stack []Op
+}
+
+// Error implements the error interface.
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Unwrap exposes the panic value when it is an error.
+func (p *PanicError) Unwrap() error {
+	if err, ok := p.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Recover converts a panic into an INTERNAL error stored in the error pointer provided.
+//
+// It must be deferred directly:
+//
+//	func handle() (err error) {
+//		defer sos.Recover(&err)
+//		...
+//	}
+//
+// The resulting error wraps a *PanicError holding the panic value and the stack of
+// the goroutine which panicked. When the panic value is an error it remains in the
+// error chain. If there is no panic then the error pointer is left untouched.
+func Recover(err *error) {
+	if v := recover(); v != nil {
+		e := recovered(v)
+		if err != nil {
+			*err = e
+		}
+	}
+}
+
+// Go calls the function provided in a new goroutine and delivers its error, or
+// the INTERNAL error produced from a panic (see Recover), on the returned channel.
+//
+// The channel receives exactly one value, which is nil on success, and is then closed.
+func Go(fn func() error) <-chan error {
+	ch := make(chan error, 1)
+
+	go func() {
+		defer close(ch)
+
+		var err error
+		func() {
+			defer Recover(&err)
+			err = fn()
+		}()
+
+		ch <- err
+	}()
+
+	return ch
+}
+
+// RecoverMiddleware recovers panics in the handler provided and renders them as
+// INTERNAL errors using the renderer provided (see Recover).
+//
+// Panics with http.ErrAbortHandler are propagated so the server aborts the response.
+func RecoverMiddleware(r HTTPRenderer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			r.RenderContext(req.Context(), w, recovered(v))
+		}()
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// recovered converts a recovered panic value into an INTERNAL error whose Op is
+// the function which panicked. It must be called from within a deferred function.
+func recovered(v interface{}) *Err {
+	p := &PanicError{Value: v, Stack: panicStack()}
+
+	e := create(context.Background(), false, 2, INTERNAL, p.Error(), p)
+	if len(p.Stack) > 0 {
+		if o, ok := p.Stack[0].(*op); ok {
+			e.op = o
+		}
+	}
+
+	return e
+}
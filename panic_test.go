@@ -0,0 +1,90 @@
+package sos_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bjaus/sos"
+)
+
+func explode(v interface{}) (err error) {
+	defer sos.Recover(&err)
+	panic(v)
+}
+
+func TestRecover(t *testing.T) {
+
+	t.Run("value", func(t *testing.T) {
+		err := explode("kaboom")
+		if got := sos.Kind(err); got != sos.INTERNAL {
+			t.Fatalf("code: got %q, want %q", got, sos.INTERNAL)
+		}
+
+		var p *sos.PanicError
+		if !errors.As(err, &p) {
+			t.Fatalf("panic error should be in the chain: %v", err)
+		}
+		if p.Value != "kaboom" {
+			t.Errorf("value: got %v, want kaboom", p.Value)
+		}
+		if op := sos.As(err).Operation(); op.Caller() != "explode" {
+			t.Errorf("op: got %q, want %q", op.Caller(), "explode")
+		}
+		if len(p.Stack) < 2 || p.Stack[1].Caller() != "TestRecover" {
+			t.Errorf("stack should include callers of the panicking function: %v", p.Stack)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		err := explode(io.ErrUnexpectedEOF)
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("original error should be in the chain: %v", err)
+		}
+	})
+
+	t.Run("no panic", func(t *testing.T) {
+		err := func() (err error) {
+			defer sos.Recover(&err)
+			return nil
+		}()
+		if err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+	})
+
+	t.Run("go", func(t *testing.T) {
+		err := <-sos.Go(func() error { panic("kaboom") })
+		if got := sos.Kind(err); got != sos.INTERNAL {
+			t.Errorf("code: got %q, want %q", got, sos.INTERNAL)
+		}
+		if err := <-sos.Go(func() error { return nil }); err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+	})
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+
+	h := sos.RecoverMiddleware(sos.HTTPRenderer{}, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("secret internals")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status: got %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	var p sos.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Detail != sos.FallbackMessage(sos.INTERNAL) {
+		t.Errorf("detail: got %q, want %q", p.Detail, sos.FallbackMessage(sos.INTERNAL))
+	}
+}
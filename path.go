@@ -0,0 +1,193 @@
+package sos
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// TrimPaths configures how file paths are displayed by Op.String and the trace
+// formatters. The zero value displays the absolute paths recorded at build time.
+//
+// It should be configured once during program initialization.
+var TrimPaths PathTrimming
+
+// PathRewrite replaces the Prefix of a file path with Replace.
+type PathRewrite struct {
+	Prefix  string
+	Replace string
+}
+
+// PathTrimming determines which parts of a file path are removed for display.
+type PathTrimming struct {
+	// Module displays files of the main module relative to the module root. The
+	// main module is determined with runtime/debug.ReadBuildInfo and also covers
+	// binaries built with -trimpath whose paths are prefixed by the module path.
+	Module bool
+	// GOPATH strips the $GOPATH/pkg/mod and $GOPATH/src directories.
+	GOPATH bool
+	// GOROOT strips the $GOROOT/src directory. Binaries built with -trimpath
+	// already record standard library paths relative to it.
+	GOROOT bool
+	// Rewrites are checked in order before any other trimming and the first
+	// matching prefix is replaced.
+	Rewrites []PathRewrite
+}
+
+// ModulePaths enables every built-in form of trimming.
+var ModulePaths = PathTrimming{Module: true, GOPATH: true, GOROOT: true}
+
+// Trim produces the display form of the file path provided.
+func (p PathTrimming) Trim(file string) string {
+	for _, r := range p.Rewrites {
+		if r.Prefix != "" && strings.HasPrefix(file, r.Prefix) {
+			return r.Replace + strings.TrimPrefix(file, r.Prefix)
+		}
+	}
+
+	if p.Module {
+		if root := moduleRoot.Load(); root != nil && strings.HasPrefix(file, *root) {
+			return strings.TrimPrefix(file, *root)
+		}
+		// Paths are prefixed with the module path rather than a directory when
+		// built with -trimpath.
+		if mod := mainModule(); mod != "" && !filepath.IsAbs(file) && strings.HasPrefix(file, mod+"/") {
+			return strings.TrimPrefix(file, mod+"/")
+		}
+	}
+
+	if p.GOROOT {
+		if root := goroot(); root != "" && strings.HasPrefix(file, root) {
+			return strings.TrimPrefix(file, root)
+		}
+	}
+
+	if p.GOPATH {
+		for _, dir := range gopaths() {
+			for _, prefix := range []string{dir + "/pkg/mod/", dir + "/src/"} {
+				if strings.HasPrefix(file, prefix) {
+					return strings.TrimPrefix(file, prefix)
+				}
+			}
+		}
+	}
+
+	return file
+}
+
+// location produces the "file:line" display form of an Op.
+func location(o Op) string {
+	return TrimPaths.Trim(o.File()) + ":" + strconv.Itoa(o.Line())
+}
+
+var (
+	mainModule = sync.OnceValue(func() string {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			return info.Main.Path
+		}
+		return ""
+	})
+
+	// moduleRoot is the directory of the main module including a trailing slash.
+	// It is learned from the first captured frame belonging to the main module.
+	moduleRoot atomic.Pointer[string]
+)
+
+// learnRoot records the root directory of the main module from a frame when it
+// has not been recorded yet. The name is the fully qualified function name.
+func learnRoot(name, file string) {
+	if moduleRoot.Load() != nil || !filepath.IsAbs(file) {
+		return
+	}
+
+	mod := mainModule()
+	if mod == "" || !strings.HasPrefix(name, mod) {
+		return
+	}
+	if rest := strings.TrimPrefix(name, mod); rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, ".") && !strings.HasPrefix(rest, "_test.") {
+		return
+	}
+
+	// Test binaries compile external test packages with a "_test" suffix.
+	pkg := strings.TrimSuffix(importPath(name), "_test")
+
+	rel := strings.TrimPrefix(strings.TrimPrefix(pkg, mod), "/")
+	dir := filepath.ToSlash(filepath.Dir(file))
+
+	if rel != "" {
+		if !strings.HasSuffix(dir, "/"+rel) {
+			return
+		}
+		dir = strings.TrimSuffix(dir, "/"+rel)
+	}
+
+	root := dir + "/"
+	moduleRoot.CompareAndSwap(nil, &root)
+}
+
+// importPath produces the import path of the package of the fully qualified function
+// name provided which is everything before the first dot following the last slash.
+func importPath(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		if j := strings.Index(name[i:], "."); j >= 0 {
+			return name[:i+j]
+		}
+		return name
+	}
+	if j := strings.Index(name, "."); j >= 0 {
+		return name[:j]
+	}
+	return name
+}
+
+// standard reports whether the import path provided belongs to the Go standard
+// library whose import paths, unlike those of modules, have no dot in their first
+// element. The main package is excluded.
+func standard(path string) bool {
+	if path == "" || path == "main" {
+		return false
+	}
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// goroot is the directory holding the standard library sources as recorded in the
+// binary including a trailing slash. It is empty for binaries built with -trimpath
+// whose standard library paths are already relative.
+var goroot = sync.OnceValue(func() string {
+	f := runtime.FuncForPC(reflect.ValueOf(runtime.GC).Pointer())
+	if f == nil {
+		return ""
+	}
+	file, _ := f.FileLine(f.Entry())
+	file = filepath.ToSlash(file)
+	if !filepath.IsAbs(file) {
+		return ""
+	}
+	// The runtime sources live in the runtime directory of the standard library.
+	return path.Dir(path.Dir(file)) + "/"
+})
+
+func gopaths() []string {
+	env := os.Getenv("GOPATH")
+	if env == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			env = filepath.Join(home, "go")
+		}
+	}
+
+	var dirs []string
+	for _, dir := range filepath.SplitList(env) {
+		if dir != "" {
+			dirs = append(dirs, filepath.ToSlash(dir))
+		}
+	}
+	return dirs
+}
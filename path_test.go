@@ -0,0 +1,118 @@
+package sos_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/bjaus/sos"
+)
+
+// stdlibFile produces the file path recorded for a standard library frame.
+func stdlibFile() string {
+	var file string
+	sort.Slice([]int{2, 1}, func(i, j int) bool {
+		_, file, _, _ = runtime.Caller(1)
+		return false
+	})
+	return filepath.ToSlash(file)
+}
+
+func TestPathTrimming(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+
+	std := stdlibFile()
+
+	cases := map[string]struct {
+		trim sos.PathTrimming
+		file string
+		want string
+	}{
+		"zero value": {
+			file: "/home/runner/work/app/main.go",
+			want: "/home/runner/work/app/main.go",
+		},
+		"rewrite": {
+			trim: sos.PathTrimming{Rewrites: []sos.PathRewrite{{Prefix: "/home/runner/work/", Replace: "~/"}}},
+			file: "/home/runner/work/app/main.go",
+			want: "~/app/main.go",
+		},
+		"first rewrite wins": {
+			trim: sos.PathTrimming{Rewrites: []sos.PathRewrite{{Prefix: "/a/", Replace: "1/"}, {Prefix: "/a/b/", Replace: "2/"}}},
+			file: "/a/b/c.go",
+			want: "1/b/c.go",
+		},
+		"goroot": {
+			trim: sos.PathTrimming{GOROOT: true},
+			file: std,
+			want: std[strings.LastIndex(std, "/sort/")+1:],
+		},
+		"goroot trimpath": {
+			trim: sos.PathTrimming{GOROOT: true},
+			file: "net/http/server.go",
+			want: "net/http/server.go",
+		},
+		"gopath module cache": {
+			trim: sos.PathTrimming{GOPATH: true},
+			file: filepath.ToSlash(gopath) + "/pkg/mod/github.com/google/go-cmp@v0.5.9/cmp/compare.go",
+			want: "github.com/google/go-cmp@v0.5.9/cmp/compare.go",
+		},
+		"gopath src": {
+			trim: sos.PathTrimming{GOPATH: true},
+			file: filepath.ToSlash(gopath) + "/src/example.com/app/main.go",
+			want: "example.com/app/main.go",
+		},
+		"trimpath": {
+			trim: sos.PathTrimming{Module: true},
+			file: "github.com/bjaus/sos/metrics/metrics.go",
+			want: "metrics/metrics.go",
+		},
+		"trimpath dependency": {
+			trim: sos.PathTrimming{Module: true},
+			file: "github.com/google/go-cmp@v0.5.9/cmp/compare.go",
+			want: "github.com/google/go-cmp@v0.5.9/cmp/compare.go",
+		},
+		"unrelated": {
+			trim: sos.ModulePaths,
+			file: "/opt/other/file.go",
+			want: "/opt/other/file.go",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.trim.Trim(tc.file); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("module relative", func(t *testing.T) {
+		prev := sos.TrimPaths
+		defer func() { sos.TrimPaths = prev }()
+		sos.TrimPaths = sos.ModulePaths
+
+		err := sos.New(sos.NOTFOUND)
+		line := err.Operation().Line()
+
+		want := fmt.Sprintf("path_test.go:%d", line)
+		if got := err.Operation().String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		if got := sos.FormatTrace(err, sos.CompilerFormatter{}); got != want+": [not found] not found error" {
+			t.Errorf("got %q", got)
+		}
+
+		// The raw path is still available for tooling.
+		if file := err.Operation().File(); filepath.IsAbs(file) {
+			if _, e := os.Stat(file); e != nil {
+				t.Error(e)
+			}
+		}
+	})
+}
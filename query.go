@@ -0,0 +1,90 @@
+package sos
+
+// Origin produces the deepest cause in the chain of the error provided which does
+// not implement the Error interface, such as the error returned by a driver.
+//
+// When several causes share the greatest depth, as with errors.Join, the first one
+// found is used. If the chain holds no such cause then the returned value is nil.
+func Origin(err error) error {
+	var origin error
+	deepest := -1
+
+	Walk(err, func(depth int, e error) bool {
+		if isOrigin(e) && depth > deepest {
+			origin, deepest = e, depth
+		}
+		return true
+	})
+
+	return origin
+}
+
+// Causes produces every root cause in the chain of the error provided which does not
+// implement the Error interface in the order they are found.
+func Causes(err error) []error {
+	var causes []error
+
+	Walk(err, func(_ int, e error) bool {
+		if isOrigin(e) {
+			causes = append(causes, e)
+		}
+		return true
+	})
+
+	return causes
+}
+
+// Find produces the first error in the chain of the error provided which satisfies
+// the predicate. If no error satisfies it then the returned value is nil.
+func Find(err error, pred func(error) bool) error {
+	var found error
+
+	Walk(err, func(_ int, e error) bool {
+		if pred(e) {
+			found = e
+			return false
+		}
+		return true
+	})
+
+	return found
+}
+
+// HasCode indicates whether any error in the chain of the error provided implements
+// the Error interface with the Code provided.
+func HasCode(err error, code Code) bool {
+	return Find(err, func(e error) bool {
+		x, ok := e.(Error)
+		return ok && x.Code() == code
+	}) != nil
+}
+
+// CodesIn produces the distinct Codes of the errors in the chain of the error provided
+// which implement the Error interface starting with the outermost.
+//
+// It is not named Codes since that name is taken by the exported list of built-in
+// Code values and renaming it would break existing callers.
+func CodesIn(err error) []Code {
+	var codes []Code
+	seen := make(map[Code]struct{})
+
+	Walk(err, func(_ int, e error) bool {
+		x, ok := e.(Error)
+		if !ok || x.Code() == "" {
+			return true
+		}
+		if _, ok := seen[x.Code()]; !ok {
+			seen[x.Code()] = struct{}{}
+			codes = append(codes, x.Code())
+		}
+		return true
+	})
+
+	return codes
+}
+
+// isOrigin reports whether the error is a root cause which does not implement the
+// Error interface.
+func isOrigin(err error) bool {
+	return err != nil && !unwraps(err) && !Is(err)
+}
@@ -0,0 +1,83 @@
+package sos_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bjaus/sos"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuery(t *testing.T) {
+	driver := errors.New("sql: no rows in result set")
+	timeout := errors.New("i/o timeout")
+
+	repo := sos.New(sos.NOTFOUND).WithError(fmt.Errorf("query: %w", driver))
+	policy := sos.As(sos.Trace(repo)).WithCode(sos.FORBIDDEN)
+	joined := errors.Join(sos.New(sos.TIMEOUT).WithError(timeout), fmt.Errorf("handler: %w", policy))
+
+	cases := map[string]struct {
+		err     error
+		origin  error
+		causes  []error
+		codes   []sos.Code
+		hasCode sos.Code
+	}{
+		"nil": {},
+		"foreign": {
+			err:    driver,
+			origin: driver,
+			causes: []error{driver},
+		},
+		"wrapped": {
+			err:     policy,
+			origin:  driver,
+			causes:  []error{driver},
+			codes:   []sos.Code{sos.FORBIDDEN, sos.NOTFOUND},
+			hasCode: sos.NOTFOUND,
+		},
+		"multi cause": {
+			err:     joined,
+			origin:  driver,
+			causes:  []error{timeout, driver},
+			codes:   []sos.Code{sos.TIMEOUT, sos.FORBIDDEN, sos.NOTFOUND},
+			hasCode: sos.NOTFOUND,
+		},
+		"no origin": {
+			err:     sos.New(sos.INVALID),
+			codes:   []sos.Code{sos.INVALID},
+			hasCode: sos.INVALID,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := sos.Origin(tc.err); got != tc.origin {
+				t.Errorf("origin: got %v, want %v", got, tc.origin)
+			}
+			if diff := cmp.Diff(sos.Causes(tc.err), tc.causes, cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+				t.Errorf("causes: %s", diff)
+			}
+			if diff := cmp.Diff(sos.CodesIn(tc.err), tc.codes); diff != "" {
+				t.Errorf("codes: %s", diff)
+			}
+			if tc.hasCode != "" && !sos.HasCode(tc.err, tc.hasCode) {
+				t.Errorf("expected code %q in chain", tc.hasCode)
+			}
+			if sos.HasCode(tc.err, sos.CONFLICT) {
+				t.Error("unexpected code in chain")
+			}
+		})
+	}
+
+	t.Run("find", func(t *testing.T) {
+		got := sos.Find(joined, func(e error) bool { return e.Error() == "i/o timeout" })
+		if got != timeout {
+			t.Errorf("got %v, want %v", got, timeout)
+		}
+		if got := sos.Find(joined, func(error) bool { return false }); got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package sos
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RedactStrategy indicates how a sensitive value is replaced.
+type RedactStrategy int
+
+// Supported redaction strategies.
+const (
+	// RedactMask replaces the sensitive value with RedactedMask.
+	RedactMask RedactStrategy = iota
+	// RedactHash replaces the sensitive value with a truncated SHA-256 hash so that
+	// equal values can still be correlated without being exposed.
+	RedactHash
+)
+
+// RedactedMask is the replacement text used by the RedactMask strategy.
+const RedactedMask = "[REDACTED]"
+
+// RedactRule describes which detail values are sensitive and how to redact them.
+//
+// A rule matches a detail when its key matches Key or KeyPattern, in which case the
+// whole value is redacted, or when the value matches Value, in which case only the
+// matching portions of the value are redacted.
+//
+// Value rules only apply to string details and messages so that typed details such
+// as those added using WithDetailInt keep their type. Typed details matched by key
+// are replaced by the redacted string.
+type RedactRule struct {
+	// Key is a glob pattern (see path.Match) matched against lowercased detail keys.
+	Key string
+	// KeyPattern is a regular expression matched against detail keys.
+	KeyPattern *regexp.Regexp
+	// Value is a regular expression matched against detail values and messages.
+	Value *regexp.Regexp
+	// Check, when provided, must report true for a Value match to be redacted. It
+	// reduces false positives of loose patterns (e.g., a checksum).
+	Check func(match string) bool
+	// Strategy determines how matched values are replaced.
+	Strategy RedactStrategy
+}
+
+// Built-in value redaction rules.
+var (
+	// RedactPAN matches payment card numbers with optional space or dash separators
+	// which pass the Luhn checksum.
+	RedactPAN = RedactRule{Value: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), Check: Luhn}
+	// RedactJWT matches JSON Web Tokens.
+	RedactJWT = RedactRule{Value: regexp.MustCompile(`\beyJ[\w-]*\.[\w-]+\.[\w-]*`)}
+	// RedactEmail matches email addresses.
+	RedactEmail = RedactRule{Value: regexp.MustCompile(`[\w.+-]+@[\w-]+(?:\.[\w-]+)+`)}
+)
+
+// RedactRules is the initial set of rules applied whenever error details are rendered.
+//
+// Redaction applies to MarshalJSON, Problem, DetailsFor, LogValue and the error trace.
+// The raw values remain available through Details.
+var RedactRules = []RedactRule{
+	{Key: "*password*"},
+	{Key: "*secret*"},
+	{Key: "*token*"},
+	{Key: "authorization"},
+	RedactPAN,
+	RedactJWT,
+	RedactEmail,
+}
+
+// redact applies the redaction rules to the detail provided.
+func redact(k, v string, sensitive bool) string {
+	if r, ok := redactKey(k, v, sensitive); ok {
+		return r
+	}
+	return redactText(v)
+}
+
+// redactKey applies the key redaction rules to the detail provided and reports
+// whether the value was redacted.
+func redactKey(k, v string, sensitive bool) (string, bool) {
+	if sensitive {
+		return RedactMask.apply(v), true
+	}
+
+	lower := strings.ToLower(k)
+
+	for _, r := range RedactRules {
+		if r.Key != "" {
+			if ok, _ := path.Match(r.Key, lower); ok {
+				return r.Strategy.apply(v), true
+			}
+		}
+		if r.KeyPattern != nil && r.KeyPattern.MatchString(k) {
+			return r.Strategy.apply(v), true
+		}
+	}
+
+	return v, false
+}
+
+// redactText applies the value redaction rules to free-form text such as messages.
+func redactText(s string) string {
+	for _, r := range RedactRules {
+		// Matching first avoids the allocations of replacing when there is nothing to redact.
+		if r.Value == nil || !r.Value.MatchString(s) {
+			continue
+		}
+		r := r
+		s = r.Value.ReplaceAllStringFunc(s, func(m string) string {
+			if r.Check != nil && !r.Check(m) {
+				return m
+			}
+			return r.Strategy.apply(m)
+		})
+	}
+	return s
+}
+
+// Luhn reports whether the digits of the value provided pass the Luhn checksum used
+// by payment card numbers. Spaces and dashes are ignored.
+func Luhn(s string) bool {
+	var sum, n int
+
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+
+	return n > 0 && sum%10 == 0
+}
+
+func (s RedactStrategy) apply(v string) string {
+	if s == RedactHash {
+		sum := sha256.Sum256([]byte(v))
+		return "sha256:" + hex.EncodeToString(sum[:6])
+	}
+	return RedactedMask
+}
+
+// redacted produces the value of the attribute with the redaction rules applied.
+//
+// Values which need no redaction keep their original type. Value rules apply to
+// string values, including those nested within maps, slices and structs whose keys
+// are matched against the key rules as well.
+func (a attr) redacted() interface{} {
+	if v, ok := a.val.(string); ok {
+		return redact(a.key, v, a.sensitive)
+	}
+	if r, ok := redactKey(a.key, stringify(a.val), a.sensitive); ok {
+		return r
+	}
+	if v, ok := redactValue(a.val); ok {
+		return v
+	}
+	return a.val
+}
+
+// redactValue applies the redaction rules to the nested values of the detail value
+// provided and reports whether anything was redacted.
+//
+// Composite values are inspected through their JSON representation so the redacted
+// value is made up of maps, slices and scalars. Values which cannot be represented
+// as JSON are redacted as a whole when their string form matches a value rule.
+func redactValue(v interface{}) (interface{}, bool) {
+	switch x := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, time.Time, time.Duration:
+		return v, false
+	case error:
+		s := x.Error()
+		if r := redactText(s); r != s {
+			return r, true
+		}
+		return v, false
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		s := stringify(v)
+		if r := redactText(s); r != s {
+			return r, true
+		}
+		return v, false
+	}
+
+	var n interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&n); err != nil {
+		return v, false
+	}
+
+	return redactNested(n)
+}
+
+// redactNested applies the redaction rules to a decoded JSON value.
+func redactNested(v interface{}) (interface{}, bool) {
+	switch x := v.(type) {
+	case string:
+		r := redactText(x)
+		return r, r != x
+	case map[string]interface{}:
+		var changed bool
+		for k, e := range x {
+			if r, ok := redactKey(k, stringify(e), false); ok {
+				x[k], changed = r, true
+				continue
+			}
+			if r, ok := redactNested(e); ok {
+				x[k], changed = r, true
+			}
+		}
+		return x, changed
+	case []interface{}:
+		var changed bool
+		for i, e := range x {
+			if r, ok := redactNested(e); ok {
+				x[i], changed = r, true
+			}
+		}
+		return x, changed
+	}
+	return v, false
+}
@@ -1,11 +1,17 @@
 package sos
 
 import (
-	"errors"
+	"context"
 	"fmt"
 )
 
-// Error ...
+// Error is implemented by every error produced by this package and by custom error
+// types which want to be traced and rendered like them.
+//
+// Matching a Code or Reason target using errors.Is relies on the Is method of Err.
+// Custom implementations are only matched once traced, which wraps them in an Err
+// value, unless they implement Is(target error) bool themselves. HasCode and Kind
+// match them either way.
 type Error interface {
 	error
 	Code() Code
@@ -15,9 +21,37 @@ type Error interface {
 	Reason() string
 }
 
+// Traceable is implemented by custom Error types which record the Op values at
+// which they are traced instead of being wrapped by an Err value.
+//
+// Since they are never wrapped, errors.Is only matches a Code or Reason target
+// against them when they implement Is(target error) bool. See Error.
+type Traceable interface {
+	Error
+	// AddOperation records the Op at which the error was traced.
+	AddOperation(op Op)
+	// Operations exposes every recorded Op starting with the most recent.
+	Operations() []Op
+}
+
 // New creates an new Err value for building out an error with desired details.
 func New(code Code) *Err {
-	return create(code, FallbackMessage(code), nil)
+	return create(context.Background(), false, 1, code, FallbackMessage(code), nil)
+}
+
+// NewSkip creates a new Err value like New where skip is the number of additional
+// stack frames to ascend when recording the Op. A skip of zero is equivalent to New.
+func NewSkip(skip int, code Code) *Err {
+	if skip < 0 {
+		skip = 0
+	}
+	return create(context.Background(), false, 1+skip, code, FallbackMessage(code), nil)
+}
+
+// NewContext creates a new Err value like New and adds the details produced by
+// the registered context extractors (see RegisterExtractor).
+func NewContext(ctx context.Context, code Code) *Err {
+	return create(ctx, true, 1, code, FallbackMessage(code), nil)
 }
 
 // Trace provides the ability to add trace the error without altering the error value.
@@ -27,47 +61,127 @@ func New(code Code) *Err {
 //
 // If the error provided is nil then the returned value is nil as well.
 // And if the error provided does not satisfy the Error interface the Code will default to INTERNAL.
+//
+// Custom Error implementations are wrapped by an Err value keeping their Code, reason,
+// message and details unless they implement Traceable in which case they record the
+// Op themselves and are returned as is.
 func Trace(err error) error {
+	return traceErr(context.Background(), false, 0, err)
+}
+
+// TraceSkip traces the error like Trace where skip is the number of additional stack
+// frames to ascend when recording the Op. A skip of zero is equivalent to Trace.
+func TraceSkip(skip int, err error) error {
+	if skip < 0 {
+		skip = 0
+	}
+	return traceErr(context.Background(), false, skip, err)
+}
+
+// TraceContext traces the error like Trace and adds the details produced by the
+// registered context extractors (see RegisterExtractor) which are not already present.
+func TraceContext(ctx context.Context, err error) error {
+	return traceErr(ctx, true, 0, err)
+}
+
+// traceErr implements Trace and TraceContext where skip is the number of additional
+// stack frames to ascend when recording the Op. The context is passed to the hooks
+// and, when extract is true, to the registered extractors.
+func traceErr(ctx context.Context, extract bool, skip int, err error) error {
 	if err == nil {
 		return nil
 	}
 
 	prev, ok := err.(*Err)
 	if ok {
-		if prev == nil || prev.op == nil {
+		if prev == nil {
 			return nil
 		}
-		op := opParser(1)
-		return prev.propagate(err, op)
+		op := capture(prev.code, 2+skip)
+		e := prev.propagate(err, op)
+		// The prior states now belong to the wrapped copy.
+		e.history = nil
+		if extract {
+			e.extract(ctx)
+		}
+		fire(hookTrace, ctx, e)
+		return e
 	}
 
-	return create(INTERNAL, err.Error(), err)
+	if x, ok := err.(Traceable); ok {
+		if op := capture(x.Code(), 2+skip); op != nil {
+			x.AddOperation(op)
+		}
+		fireError(hookTrace, ctx, x)
+		return x
+	}
+
+	if x, ok := err.(Error); ok {
+		e := create(ctx, extract, 2+skip, x.Code(), FallbackMessage(x.Code()), nil).wrap(x)
+		fire(hookTrace, ctx, e)
+		return e
+	}
+
+	e := create(ctx, extract, 2+skip, INTERNAL, err.Error(), err)
+	e.classify(ctx)
+	return e
 }
 
 // Is indicates whether the error provided implements the Error interface.
 func Is(err error) bool {
-	var i Error
-	return errors.As(err, &i)
+	return find(err) != nil
 }
 
 // As converts the error provided into an Err value if the error implements the Error interface.
 //
-// If the error does not implement the Error interface then the returned value is nil.
+// The first error in the chain implementing the Error interface is used. If it is an
+// Err value it is returned as is. Custom Error implementations are returned as a
+// read-only view holding their Code, reason, message and details which has no ID;
+// changes made to the view using the builder methods are not reflected by the
+// original error. If the error does not implement the Error interface then the
+// returned value is nil.
 func As(err error) *Err {
-	if e := new(Err); errors.As(err, &e) {
-		return e
+	switch x := find(err).(type) {
+	case nil:
+		return nil
+	case *Err:
+		return x
+	default:
+		return convert(x)
+	}
+}
+
+// convert produces the read-only view of a custom Error implementation holding its
+// Code, reason, message and details without recording an Op or assigning an ID.
+func convert(x Error) *Err {
+	e := &Err{
+		code:    x.Code(),
+		message: FallbackMessage(x.Code()),
 	}
-	return nil
+	return e.wrap(x)
 }
 
-// Must acts similiar to the As function but will panic if the error does
-// not satisfy the Error interface.
+// wrap attaches the custom Error implementation provided as the cause of the error
+// taking over its reason, which falls back to the Code when it has none.
+func (e *Err) wrap(x Error) *Err {
+	e.reason = ""
+	e.propagate(x)
+	if e.reason == "" {
+		e.reason = string(e.code)
+	}
+	return e
+}
+
+// Must acts similiar to the As function but will panic if the error is not
+// an Err value.
 //
 // This is handy for instances where you know the error is already the
 // proper error type and want to quickly access the builder methods
-// to make changes to the state of the error.
+// to make changes to the state of the error. Custom Error implementations
+// cause a panic as well since changes made to their view would be lost;
+// use Trace to wrap them in an Err value first.
 func Must(err error) *Err {
-	if e := As(err); e != nil {
+	if e, ok := find(err).(*Err); ok {
 		return e
 	}
 	panic(fmt.Errorf("invalid error type: %v", err))
@@ -77,23 +191,45 @@ func Must(err error) *Err {
 //
 // If the error does not satisfy the Error interface or is nil then an empty Code value is returned..
 func Kind(err error) Code {
-	if err != nil {
-		if e := Error(nil); errors.As(err, &e) {
-			return e.Code()
-		}
+	if e := find(err); e != nil {
+		return e.Code()
 	}
 	return ""
 }
 
-func create(code Code, msg string, err error) *Err {
+// find produces the first error in the chain which implements the Error interface.
+func find(err error) Error {
+	var e Error
+	Walk(err, func(_ int, w error) bool {
+		if x, ok := w.(Error); ok {
+			if p, ok := x.(*Err); ok && p == nil {
+				return true
+			}
+			e = x
+			return false
+		}
+		return true
+	})
+	return e
+}
+
+// create builds a new Err value where skip is the number of stack frames between
+// create and the call site which should be recorded as the Op. The context is passed
+// to the hooks and, when extract is true, to the registered extractors.
+func create(ctx context.Context, extract bool, skip int, code Code, msg string, err error) *Err {
 	e := Err{
+		id:      newID(),
 		code:    code,
 		message: msg,
 		reason:  string(code),
-		op:      opParser(2),
-		detail:  make(map[string]string),
+		op:      capture(code, skip+1),
 		err:     err,
 	}
 
+	if extract {
+		e.extract(ctx)
+	}
+	fire(hookCreate, ctx, &e)
+
 	return &e
 }
@@ -1,10 +1,12 @@
 package sos_test
 
 import (
+	"errors"
 	"fmt"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bjaus/sos"
 	"github.com/google/go-cmp/cmp"
@@ -500,8 +502,136 @@ func TestKind(t *testing.T) {
 	}
 }
 
+func TestErrorsIs(t *testing.T) {
+
+	inner := sos.New(sos.NOTFOUND).WithReason("card-declined")
+	outer := sos.As(sos.Trace(inner)).WithCode(sos.FORBIDDEN)
+	foreign := fmt.Errorf("handler: %w", outer)
+
+	cases := map[string]struct {
+		err    error
+		target error
+		want   bool
+	}{
+		"code": {
+			err:    sos.New(sos.NOTFOUND),
+			target: sos.NOTFOUND,
+			want:   true,
+		},
+		"other code": {
+			err:    sos.New(sos.NOTFOUND),
+			target: sos.CONFLICT,
+			want:   false,
+		},
+		"reason": {
+			err:    inner,
+			target: sos.Reason("card-declined"),
+			want:   true,
+		},
+		"other reason": {
+			err:    inner,
+			target: sos.Reason("expired-card"),
+			want:   false,
+		},
+		"code as reason": {
+			err:    sos.New(sos.NOTFOUND),
+			target: sos.Reason(string(sos.NOTFOUND)),
+			want:   false,
+		},
+		"inner code through foreign error": {
+			err:    foreign,
+			target: sos.NOTFOUND,
+			want:   true,
+		},
+		"outer code through foreign error": {
+			err:    foreign,
+			target: sos.FORBIDDEN,
+			want:   true,
+		},
+		"foreign error": {
+			err:    fmt.Errorf("external error"),
+			target: sos.INTERNAL,
+			want:   false,
+		},
+		"nil": {
+			err:    nil,
+			target: sos.NOTFOUND,
+			want:   false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := errors.Is(tc.err, tc.target); got != tc.want {
+				t.Errorf("%s: got %v, want %v", name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReasonAdoption(t *testing.T) {
+
+	inner := sos.New(sos.NOTFOUND).WithReason("gone")
+
+	if got := sos.As(sos.Trace(inner)).Reason(); got != "gone" {
+		t.Errorf("trace: got %q, want %q", got, "gone")
+	}
+
+	err := sos.As(sos.Trace(inner)).WithResetReason().WithDetail("k", "v")
+	if got := err.Reason(); got != string(sos.NOTFOUND) {
+		t.Errorf("reset: got %q, want %q", got, sos.NOTFOUND)
+	}
+}
+
 type testerror struct{}
 
 func (ce testerror) Error() string {
 	return "custom error"
 }
+
+func TestID(t *testing.T) {
+
+	t.Run("ulid", func(t *testing.T) {
+		a := sos.NewULID()
+		time.Sleep(2 * time.Millisecond)
+		b := sos.NewULID()
+		if len(a) != 26 || len(b) != 26 {
+			t.Fatalf("length: got %d and %d, want 26", len(a), len(b))
+		}
+		if a >= b {
+			t.Errorf("ids should sort by creation: %s >= %s", a, b)
+		}
+	})
+
+	t.Run("propagation", func(t *testing.T) {
+		gen := sos.IDGenerator
+		t.Cleanup(func() { sos.IDGenerator = gen })
+
+		var n int
+		sos.IDGenerator = func() string {
+			n++
+			return fmt.Sprintf("id-%d", n)
+		}
+
+		inner := sos.New(sos.NOTFOUND)
+		if inner.ID() != "id-1" {
+			t.Fatalf("id: got %q, want %q", inner.ID(), "id-1")
+		}
+
+		err := sos.Trace(inner)
+		if id := sos.As(err).ID(); id != "id-1" {
+			t.Errorf("trace: got %q, want %q", id, "id-1")
+		}
+
+		outer := sos.New(sos.FORBIDDEN).WithError(err)
+		if outer.ID() != "id-1" {
+			t.Errorf("with error: got %q, want %q", outer.ID(), "id-1")
+		}
+		if p := outer.Problem(sos.AudiencePublic); p.Instance != "id-1" {
+			t.Errorf("instance: got %q, want %q", p.Instance, "id-1")
+		}
+		if !strings.HasPrefix(outer.Error(), "id: id-1\n") {
+			t.Errorf("trace header should include id: %s", outer.Error())
+		}
+	})
+}
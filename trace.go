@@ -1,108 +1,177 @@
 package sos
 
-import (
-	"errors"
-	"fmt"
-	"strings"
-)
-
 func trace(e *Err) string {
+	return FormatTrace(e, nil)
+}
 
-	var t tracer
+// Hop is a single step of an error trace made up of the Code and message of an
+// error along with every call site it passed through.
+type Hop struct {
+	Code    Code
+	Message string
+	Reason  string
+	// Details are the details added or changed by the hop.
+	Details DetailList
+	// Frames are the call sites ordered from oldest to most recent.
+	Frames []Op
+}
+
+// TraceInfo holds the data making up an error trace.
+type TraceInfo struct {
+	// ID is the instance ID of the outermost error.
+	ID string
+	// Origins are the messages of the foreign errors at the end of the chain.
+	Origins []string
+	// Hops are ordered from the origin of the error to the most recent.
+	Hops []Hop
+	// Truncated reports whether the chain was deeper than MaxDepth in which case the
+	// oldest hops and origins are missing.
+	Truncated bool
+}
 
-	t.add(e.message, e.code, e.op)
+// TraceOf collects the trace data of the error provided.
+//
+// Messages are redacted according to RedactRules.
+func TraceOf(err error) TraceInfo {
+	return traceOf(err, true)
+}
 
-	w := errors.Unwrap(e)
-	t.origin(w)
+// traceOf implements TraceOf where the details of the hops are only collected when
+// details is true.
+func traceOf(err error, details bool) TraceInfo {
+	var t tracer
+
+	if e, ok := find(err).(*Err); ok {
+		t.id = e.id
+	}
 
-	for w != nil {
-		if x := new(Err); errors.As(w, &x) {
-			if x.op == nil {
-				continue
-			}
-			t.add(x.message, x.code, x.op)
+	var xs []Error
+	truncated := walk(err, func(_ int, w error) bool {
+		if x, ok := w.(Error); ok {
+			xs = append(xs, x)
 		} else {
 			t.origin(w)
 		}
-
-		w = errors.Unwrap(w)
+		return true
+	})
+
+	// The history starts with the origin whereas hops are grouped starting with
+	// the most recent.
+	hops := hopsFrom(xs, details)
+	for i := len(hops) - 1; i >= 0; i-- {
+		t.add(hops[i])
 	}
 
-	return t.String()
+	info := t.info()
+	info.Truncated = truncated
+
+	return info
+}
+
+// operations produces the Op values recorded by an Error starting with the most recent.
+func operations(x Error) []Op {
+	if e, ok := x.(*Err); ok {
+		if e == nil || e.op == nil {
+			return nil
+		}
+		return []Op{e.op}
+	}
+	if t, ok := x.(Traceable); ok {
+		return t.Operations()
+	}
+	if op := x.Operation(); op != nil {
+		return []Op{op}
+	}
+	return nil
 }
 
 type tracer struct {
-	// o is the error of origin whchi is any non-nil error which does not implement the Error interface.
-	o error
-	// k is a slice of messages used to loop over the map in order.
-	k []string
-	// m is the message to file paths mapping used to produce the trace.
-	m map[string][]string
-	// s is a set to for deduplication.
-	s map[string]struct{}
+	// id is the instance ID of the outermost error which is included in the trace header.
+	id string
+	// o are the errors of origin which are the non-nil errors at the end of the chain
+	// which do not implement the Error interface.
+	o []error
+	// h are the hops in the order they were found which is most recent first.
+	h []*Hop
+	// k is the message key to hop mapping used to group call sites.
+	k map[hopKey]*Hop
+	// s is a set of call sites for deduplication.
+	s map[site]struct{}
+}
+
+// hopKey groups the call sites of hops sharing the same Code and message.
+type hopKey struct {
+	code    Code
+	message string
 }
 
-func (t *tracer) add(m string, code Code, op Op) {
+// site is the file path and line number of a call site.
+type site struct {
+	file string
+	line int
+}
+
+func (t *tracer) add(x hop) {
 	// Avoid unalloc panic
 	if t.s == nil {
-		t.s = make(map[string]struct{})
+		t.s = make(map[site]struct{})
 	}
-	if t.m == nil {
-		t.m = make(map[string][]string)
+	if t.k == nil {
+		t.k = make(map[hopKey]*Hop)
 	}
 
-	if op == nil {
-		return
+	// Create the message key.
+	k := hopKey{code: x.code, message: x.message}
+
+	h, ok := t.k[k]
+	if !ok {
+		h = &Hop{Code: x.code, Message: x.message, Reason: x.reason}
+		t.k[k] = h
+		t.h = append(t.h, h)
 	}
 
-	// Create the message key.
-	k := fmt.Sprintf("[%s] %s", code, m)
+	// Hops are added starting with the most recent so older details go first.
+	if len(x.added) > 0 {
+		h.Details = append(x.added[:len(x.added):len(x.added)], h.Details...)
+	}
+
+	if x.op == nil {
+		return
+	}
 
 	// Creat the filepath with line number.
-	p := fmt.Sprintf("%s:%d", op.File(), op.Line())
+	p := site{file: x.op.File(), line: x.op.Line()}
 
 	if _, ok := t.s[p]; !ok {
-		t.m[k] = append(t.m[k], p)
+		h.Frames = append(h.Frames, x.op)
 		t.s[p] = struct{}{}
 	}
-
-	if _, ok := t.s[k]; !ok {
-		t.k = append(t.k, k)
-		t.s[k] = struct{}{}
-	}
 }
 
 func (t *tracer) origin(err error) {
-	if err == nil {
-		return
-	} else if x := As(err); x == nil {
-		t.o = err
+	if isOrigin(err) {
+		t.o = append(t.o, err)
 	}
 }
 
-func (t *tracer) String() string {
+func (t *tracer) info() TraceInfo {
+	info := TraceInfo{ID: t.id}
 
 	// Loop backwards over all data collected to order properly.
 
-	var b strings.Builder
-
-	for i := len(t.k) - 1; i >= 0; i-- {
-		msg := t.k[i]
-		fmt.Fprint(&b, msg)
-
-		for j := len(t.m[msg]) - 1; j >= 0; j-- {
-			path := t.m[msg][j]
-			fmt.Fprintf(&b, "\n\t%s", path)
-		}
-
-		fmt.Fprint(&b, "\n")
+	for i := len(t.o) - 1; i >= 0; i-- {
+		info.Origins = append(info.Origins, redactText(t.o[i].Error()))
 	}
 
-	s := strings.TrimSpace(b.String())
-
-	if t.o != nil && !strings.Contains(s, t.o.Error()) {
-		return fmt.Sprintf("%s\n%s", t.o.Error(), s)
+	for i := len(t.h) - 1; i >= 0; i-- {
+		h := *t.h[i]
+		frames := make([]Op, len(h.Frames))
+		for j := range h.Frames {
+			frames[j] = h.Frames[len(h.Frames)-1-j]
+		}
+		h.Frames = frames
+		info.Hops = append(info.Hops, h)
 	}
 
-	return s
+	return info
 }
@@ -0,0 +1,217 @@
+package sos
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LinkStyle determines how file paths are hyperlinked by the TTYFormatter.
+type LinkStyle int
+
+const (
+	// LinkNone disables hyperlinks.
+	LinkNone LinkStyle = iota
+	// LinkFile links to file:// URLs.
+	LinkFile
+	// LinkVSCode links to vscode:// URLs which open the file at the line in VS Code.
+	LinkVSCode
+	// LinkGoLand links to goland:// URLs which open the file at the line in GoLand.
+	LinkGoLand
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// TTYFormatter produces the same layout as the TextFormatter decorated for a terminal.
+//
+// Codes are colored by severity, standard library frames are dimmed and file paths
+// are OSC 8 hyperlinks.
+type TTYFormatter struct {
+	// Color enables ANSI colors.
+	Color bool
+	// Links determines how file paths are hyperlinked.
+	Links LinkStyle
+	// Snippet is the number of source lines shown on each side of a frame. A
+	// value of zero disables snippets.
+	Snippet int
+}
+
+var _ TraceFormatter = TTYFormatter{}
+
+// NewTTYFormatter creates a TTYFormatter for the file provided (e.g., os.Stderr).
+//
+// Colors and file hyperlinks are enabled when ColorEnabled reports true for the file.
+func NewTTYFormatter(f *os.File) TTYFormatter {
+	if !ColorEnabled(f) {
+		return TTYFormatter{Snippet: 2}
+	}
+	return TTYFormatter{Color: true, Links: LinkFile, Snippet: 2}
+}
+
+// ColorEnabled reports whether colored output should be written to the file provided.
+//
+// A non-empty NO_COLOR environment variable disables colors. Otherwise SOS_COLOR
+// may be set to "always" or "never" to override detection of a terminal.
+func ColorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	switch strings.ToLower(os.Getenv("SOS_COLOR")) {
+	case "always", "true", "1":
+		return true
+	case "never", "false", "0":
+		return false
+	}
+
+	if f == nil || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Format implements the TraceFormatter interface.
+func (f TTYFormatter) Format(t TraceInfo) string {
+	var b strings.Builder
+
+	if t.ID != "" {
+		b.WriteString(f.paint(ansiDim, "id: "+t.ID))
+		b.WriteString("\n")
+	}
+	if t.Truncated {
+		b.WriteString(f.paint(ansiDim, truncatedMarker))
+		b.WriteString("\n")
+	}
+
+	s := TextFormatter{}.Format(TraceInfo{Hops: t.Hops})
+	for _, o := range t.Origins {
+		if !strings.Contains(s, o) {
+			b.WriteString(f.paint(ansiBold, o))
+			b.WriteString("\n")
+		}
+	}
+
+	for i, h := range t.Hops {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(f.paint(severity(h.Code), "["+string(h.Code)+"]"))
+		b.WriteString(" ")
+		b.WriteString(h.Message)
+
+		for _, op := range h.Frames {
+			loc := location(op)
+			if stdlib(op) {
+				loc = f.paint(ansiDim, loc)
+			}
+			b.WriteString("\n\t")
+			b.WriteString(f.link(op.File(), op.Line(), loc))
+			b.WriteString(f.snippet(op.File(), op.Line()))
+		}
+	}
+
+	return b.String()
+}
+
+func (f TTYFormatter) paint(color, s string) string {
+	if !f.Color {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+func (f TTYFormatter) link(file string, line int, text string) string {
+	var u string
+
+	switch f.Links {
+	case LinkFile:
+		u = (&url.URL{Scheme: "file", Path: filepath.ToSlash(file)}).String()
+	case LinkVSCode:
+		u = "vscode://file" + (&url.URL{Path: filepath.ToSlash(file)}).EscapedPath() + ":" + strconv.Itoa(line)
+	case LinkGoLand:
+		u = "goland://open?file=" + url.QueryEscape(file) + "&line=" + strconv.Itoa(line)
+	default:
+		return text
+	}
+
+	return "\x1b]8;;" + u + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+func (f TTYFormatter) snippet(file string, line int) string {
+	if f.Snippet <= 0 || line <= 0 {
+		return ""
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+
+	lines := strings.Split(string(b), "\n")
+	if line > len(lines) {
+		return ""
+	}
+
+	first, last := line-f.Snippet, line+f.Snippet
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+
+	width := len(strconv.Itoa(last))
+
+	var s strings.Builder
+	for n := first; n <= last; n++ {
+		text := fmt.Sprintf("%*d | %s", width, n, strings.TrimRight(lines[n-1], "\r"))
+		if n == line {
+			s.WriteString("\n\t> " + f.paint(ansiBold, text))
+		} else {
+			s.WriteString("\n\t  " + f.paint(ansiDim, text))
+		}
+	}
+
+	return s.String()
+}
+
+// severity produces the color of a Code based on its HTTP status.
+func severity(c Code) string {
+	switch status := HTTPStatus(c); {
+	case status >= 500:
+		return ansiRed
+	case status >= 400:
+		return ansiYellow
+	default:
+		return ansiCyan
+	}
+}
+
+// stdlib reports whether the Op provided belongs to the Go standard library.
+//
+// The package import path is used rather than the file path since binaries built
+// with -trimpath record no GOROOT.
+func stdlib(o Op) bool {
+	x, ok := o.(*op)
+	if !ok {
+		return false
+	}
+	x.resolve()
+	return standard(x.path)
+}
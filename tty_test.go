@@ -0,0 +1,99 @@
+package sos_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjaus/sos"
+)
+
+func TestColorEnabled(t *testing.T) {
+
+	cases := map[string]struct {
+		noColor  string
+		sosColor string
+		want     bool
+	}{
+		"not a terminal":    {want: false},
+		"forced":            {sosColor: "always", want: true},
+		"disabled":          {sosColor: "never", want: false},
+		"NO_COLOR wins":     {noColor: "1", sosColor: "always", want: false},
+		"empty NO_COLOR":    {noColor: "", sosColor: "always", want: true},
+		"case insensitive":  {sosColor: "ALWAYS", want: true},
+		"unknown SOS_COLOR": {sosColor: "maybe", want: false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tc.noColor)
+			t.Setenv("SOS_COLOR", tc.sosColor)
+
+			f, err := os.CreateTemp(t.TempDir(), "out")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if got := sos.ColorEnabled(f); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTTYFormatter(t *testing.T) {
+	err, _, _ := formatted()
+	info := sos.TraceOf(err)
+	op := sos.As(err).Operation()
+
+	t.Run("plain matches text", func(t *testing.T) {
+		got := sos.FormatTrace(err, sos.TTYFormatter{})
+		if want := sos.FormatTrace(err, sos.TextFormatter{}); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("color", func(t *testing.T) {
+		got := sos.TTYFormatter{Color: true}.Format(info)
+		if !strings.Contains(got, "\x1b[33m[not found]\x1b[0m") {
+			t.Errorf("code is not colored by severity: %q", got)
+		}
+		got = sos.FormatTrace(sos.New(sos.INTERNAL), sos.TTYFormatter{Color: true})
+		if !strings.Contains(got, "\x1b[31m[internal]\x1b[0m") {
+			t.Errorf("code is not colored by severity: %q", got)
+		}
+	})
+
+	t.Run("links", func(t *testing.T) {
+		loc := fmt.Sprintf("%s:%d", op.File(), op.Line())
+
+		cases := map[sos.LinkStyle]string{
+			sos.LinkFile:   "file://" + op.File(),
+			sos.LinkVSCode: fmt.Sprintf("vscode://file%s:%d", op.File(), op.Line()),
+			sos.LinkGoLand: "goland://open?file=",
+		}
+		for style, prefix := range cases {
+			got := sos.TTYFormatter{Links: style}.Format(info)
+			if !strings.Contains(got, "\x1b]8;;"+prefix) || !strings.Contains(got, "\x1b\\"+loc+"\x1b]8;;\x1b\\") {
+				t.Errorf("%d: missing hyperlink: %q", style, got)
+			}
+		}
+	})
+
+	t.Run("snippet", func(t *testing.T) {
+		if !filepath.IsAbs(op.File()) {
+			t.Skip("source is unavailable when built with -trimpath")
+		}
+		got := sos.TTYFormatter{Snippet: 1}.Format(info)
+		want := fmt.Sprintf("\t> %d | \terr = sos.Trace(err)", op.Line())
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in %q", want, got)
+		}
+		if !strings.Contains(got, fmt.Sprintf("\t  %d | ", op.Line()+1)) {
+			t.Errorf("missing context lines in %q", got)
+		}
+	})
+}
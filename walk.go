@@ -0,0 +1,94 @@
+package sos
+
+import (
+	"reflect"
+)
+
+// MaxDepth limits how deep Walk descends into an error chain so that malformed
+// chains can never hang the caller.
+//
+// An Err value wrapping another Err value, as produced by every Trace, does not count
+// towards the limit so that errors passed through many layers keep their causes.
+var MaxDepth = 64
+
+// Walk calls the function provided for every error in the chain of the error
+// provided in depth-first order, starting with the error itself at depth zero.
+//
+// Both Unwrap() error and Unwrap() []error are supported. Errors which have already
+// been visited are skipped so self-referencing chains terminate, and errors deeper
+// than MaxDepth are never visited. Walking stops once the function returns false.
+func Walk(err error, fn func(depth int, e error) bool) {
+	walk(err, fn)
+}
+
+// walk implements Walk and reports whether errors were left unvisited because of MaxDepth.
+func walk(err error, fn func(depth int, e error) bool) (truncated bool) {
+	if err == nil {
+		return false
+	}
+
+	w := walker{fn: fn, seen: make(map[error]struct{})}
+	w.walk(err, 0, 0)
+	return w.truncated
+}
+
+type walker struct {
+	fn        func(int, error) bool
+	seen      map[error]struct{}
+	stop      bool
+	truncated bool
+}
+
+// walk visits the error where depth is its position in the chain and level is the
+// depth counted towards MaxDepth.
+func (w *walker) walk(err error, depth, level int) {
+	if err == nil || w.stop {
+		return
+	}
+	if level > MaxDepth {
+		w.truncated = true
+		return
+	}
+
+	// Only errors with pointer identity are tracked since other values may not be
+	// hashable. Those are still bounded by MaxDepth.
+	if reflect.TypeOf(err).Kind() == reflect.Ptr {
+		if _, ok := w.seen[err]; ok {
+			return
+		}
+		w.seen[err] = struct{}{}
+	}
+
+	if !w.fn(depth, err) {
+		w.stop = true
+		return
+	}
+
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		next := x.Unwrap()
+		// Err values wrapping Err values are bounded by the cycle detection.
+		_, outer := err.(*Err)
+		_, inner := next.(*Err)
+		if outer && inner {
+			w.walk(next, depth+1, level)
+		} else {
+			w.walk(next, depth+1, level+1)
+		}
+	case interface{ Unwrap() []error }:
+		for _, e := range x.Unwrap() {
+			w.walk(e, depth+1, level+1)
+		}
+	}
+}
+
+// unwraps reports whether the error wraps at least one other error.
+func unwraps(err error) bool {
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		return x.Unwrap() != nil
+	case interface{ Unwrap() []error }:
+		return len(x.Unwrap()) > 0
+	}
+	return false
+}
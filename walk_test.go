@@ -0,0 +1,138 @@
+package sos_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjaus/sos"
+	"github.com/google/go-cmp/cmp"
+)
+
+type loop struct{ next error }
+
+func (l *loop) Error() string { return "loop" }
+func (l *loop) Unwrap() error { return l.next }
+
+type chain int
+
+func (c chain) Error() string { return fmt.Sprintf("chain %d", int(c)) }
+func (c chain) Unwrap() error { return c + 1 }
+
+func TestWalk(t *testing.T) {
+
+	t.Run("multi", func(t *testing.T) {
+		a, b := errors.New("a"), errors.New("b")
+		err := fmt.Errorf("outer: %w", errors.Join(a, b))
+
+		var got []string
+		sos.Walk(err, func(depth int, e error) bool {
+			got = append(got, fmt.Sprintf("%d:%s", depth, e.Error()))
+			return true
+		})
+
+		want := []string{"0:outer: a\nb", "1:a\nb", "2:a", "2:b"}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		var n int
+		sos.Walk(fmt.Errorf("a: %w", errors.New("b")), func(int, error) bool {
+			n++
+			return false
+		})
+		if n != 1 {
+			t.Errorf("visits: got %d, want 1", n)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		l := &loop{}
+		l.next = l
+
+		var n int
+		sos.Walk(l, func(int, error) bool {
+			n++
+			return true
+		})
+		if n != 1 {
+			t.Errorf("visits: got %d, want 1", n)
+		}
+	})
+
+	t.Run("depth", func(t *testing.T) {
+		var max int
+		sos.Walk(chain(0), func(depth int, _ error) bool {
+			max = depth
+			return true
+		})
+		if max != sos.MaxDepth {
+			t.Errorf("depth: got %d, want %d", max, sos.MaxDepth)
+		}
+	})
+
+	t.Run("traced", func(t *testing.T) {
+		root := errors.New("connection refused")
+
+		var err error = sos.New(sos.TEMPORARY).WithError(root)
+		for i := 0; i < 2*sos.MaxDepth; i++ {
+			err = sos.Trace(err)
+		}
+
+		if got := sos.Origin(err); got != root {
+			t.Errorf("origin: got %v, want %v", got, root)
+		}
+		if s := err.Error(); !strings.Contains(s, "connection refused") || strings.Contains(s, "truncated") {
+			t.Errorf("trace: got %q", s)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		err := sos.New(sos.INVALID).WithError(chain(0))
+
+		if !sos.TraceOf(err).Truncated {
+			t.Error("truncated: got false, want true")
+		}
+		if s := err.Error(); !strings.Contains(s, "... (truncated)") {
+			t.Errorf("trace: got %q", s)
+		}
+		if s := sos.FormatTrace(err, sos.LogfmtFormatter{}); !strings.Contains(s, "truncated=true") {
+			t.Errorf("logfmt: got %q", s)
+		}
+	})
+}
+
+func TestMalformedChains(t *testing.T) {
+
+	l := &loop{}
+	l.next = l
+
+	cases := map[string]error{
+		"nil op":         sos.Trace(fmt.Errorf("wrap: %w", &sos.Err{})),
+		"self reference": sos.New(sos.INVALID).WithError(l),
+		"endless":        sos.New(sos.INVALID).WithError(chain(0)),
+	}
+
+	for name, err := range cases {
+		t.Run(name, func(t *testing.T) {
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_ = err.Error()
+				_ = sos.Kind(err)
+				_ = sos.As(err)
+				_ = sos.Fingerprint(err)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("walking the chain did not terminate")
+			}
+		})
+	}
+}
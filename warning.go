@@ -0,0 +1,241 @@
+package sos
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Severity indicates how much a warning should concern the client.
+type Severity int
+
+// Supported warning severities.
+const (
+	// SeverityInfo indicates a warning which requires no action (i.e., informational notices).
+	SeverityInfo Severity = iota
+	// SeverityWarning indicates a warning which should be acted upon (i.e., deprecated fields).
+	SeverityWarning
+	// SeverityDegraded indicates a response which is incomplete or otherwise degraded.
+	SeverityDegraded
+)
+
+// String implements the fmt.Stringer interface.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityDegraded:
+		return "degraded"
+	}
+	return "unknown"
+}
+
+// Warning communicates a problem to the client of an operation which otherwise succeeded.
+//
+// Warnings share the Code, reason and details model of Err values but are always
+// intended to be shown to clients so their messages and details should be public.
+type Warning struct {
+	code     Code
+	reason   string
+	message  string
+	severity Severity
+	detail   map[string]string
+}
+
+// NewWarning creates a new Warning value for building out a warning with desired details.
+func NewWarning(code Code) *Warning {
+	return &Warning{
+		code:     code,
+		reason:   string(code),
+		severity: SeverityWarning,
+	}
+}
+
+// Code exposes the warning Code value.
+func (w *Warning) Code() Code {
+	return w.code
+}
+
+// Reason exposes the warning reason code.
+func (w *Warning) Reason() string {
+	return w.reason
+}
+
+// Message exposes the warning message.
+//
+// If no message has been set a message based on the Code is returned.
+func (w *Warning) Message() string {
+	if w.message == "" {
+		return fmt.Sprintf("%s warning", w.code)
+	}
+	return w.message
+}
+
+// Severity exposes the warning severity.
+func (w *Warning) Severity() Severity {
+	return w.severity
+}
+
+// Details exposes the warning details map which is nil when no details were added.
+func (w *Warning) Details() map[string]string {
+	return w.detail
+}
+
+// WithMessage adds a warning message.
+func (w *Warning) WithMessage(msg string, args ...interface{}) *Warning {
+	w.message = sprintf(msg, args...)
+	return w
+}
+
+// WithReason adds a warning reason code.
+func (w *Warning) WithReason(r string) *Warning {
+	w.reason = r
+	return w
+}
+
+// WithSeverity changes the warning severity.
+func (w *Warning) WithSeverity(s Severity) *Warning {
+	w.severity = s
+	return w
+}
+
+// WithDetail adds a single key-value detail to the warning details map.
+func (w *Warning) WithDetail(k string, v string) *Warning {
+	if w.detail == nil {
+		w.detail = make(map[string]string)
+	}
+	w.detail[k] = v
+	return w
+}
+
+// WithDetails adds multiple key-value details to the warning details map.
+func (w *Warning) WithDetails(d map[string]string) *Warning {
+	if w.detail == nil && len(d) > 0 {
+		w.detail = make(map[string]string, len(d))
+	}
+	for k, v := range d {
+		w.detail[k] = v
+	}
+	return w
+}
+
+type warningJSON struct {
+	Code     Code              `json:"code"`
+	Reason   string            `json:"reason"`
+	Message  string            `json:"message"`
+	Severity string            `json:"severity"`
+	Details  map[string]string `json:"details,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+//
+// Sensitive details are redacted according to RedactRules.
+func (w *Warning) MarshalJSON() ([]byte, error) {
+	if w == nil {
+		return json.Marshal(nil)
+	}
+
+	v := warningJSON{
+		Code:     w.code,
+		Reason:   w.reason,
+		Message:  redactText(w.Message()),
+		Severity: w.severity.String(),
+	}
+
+	if len(w.detail) > 0 {
+		v.Details = make(map[string]string, len(w.detail))
+		for k, d := range w.detail {
+			v.Details[k] = redact(k, d, false)
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (w *Warning) UnmarshalJSON(b []byte) error {
+	var v warningJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*w = Warning{
+		code:    v.Code,
+		reason:  v.Reason,
+		message: v.Message,
+		detail:  v.Details,
+	}
+
+	for s := SeverityInfo; s <= SeverityDegraded; s++ {
+		if s.String() == v.Severity {
+			w.severity = s
+		}
+	}
+
+	return nil
+}
+
+// WarningHeader is the RFC 7234 HTTP header used to communicate warnings.
+const WarningHeader = "Warning"
+
+// WriteWarningHeader adds the warnings provided to the headers as RFC 7234 Warning headers.
+//
+// Degraded warnings use the 199 warn-code since they are specific to the response
+// while every other warning uses the 299 persistent warn-code.
+func WriteWarningHeader(h http.Header, ws ...*Warning) {
+	for _, w := range ws {
+		if w == nil {
+			continue
+		}
+
+		code := 299
+		if w.severity == SeverityDegraded {
+			code = 199
+		}
+
+		text := fmt.Sprintf("%s: %s", w.reason, redactText(w.Message()))
+		text = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text)
+
+		h.Add(WarningHeader, fmt.Sprintf(`%d - "%s"`, code, text))
+	}
+}
+
+// WarningTrailerKey is the gRPC metadata key used by WarningTrailer.
+//
+// The "-bin" suffix instructs gRPC to base64 encode the values on the wire.
+const WarningTrailerKey = "sos-warning-bin"
+
+// WarningTrailer encodes the warnings provided as gRPC trailer metadata.
+//
+// The returned value is compatible with google.golang.org/grpc/metadata.MD and
+// can be sent using grpc.SetTrailer.
+func WarningTrailer(ws ...*Warning) (map[string][]string, error) {
+	md := make(map[string][]string)
+	for _, w := range ws {
+		if w == nil {
+			continue
+		}
+		b, err := json.Marshal(w)
+		if err != nil {
+			return nil, err
+		}
+		md[WarningTrailerKey] = append(md[WarningTrailerKey], string(b))
+	}
+	return md, nil
+}
+
+// ParseWarningTrailer decodes the warnings encoded in gRPC trailer metadata by WarningTrailer.
+func ParseWarningTrailer(md map[string][]string) ([]*Warning, error) {
+	var ws []*Warning
+	for _, v := range md[WarningTrailerKey] {
+		w := new(Warning)
+		if err := json.Unmarshal([]byte(v), w); err != nil {
+			return nil, err
+		}
+		ws = append(ws, w)
+	}
+	return ws, nil
+}
@@ -0,0 +1,77 @@
+package sos_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bjaus/sos"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWarning(t *testing.T) {
+
+	deprecated := sos.NewWarning(sos.INVALID).
+		WithReason("deprecated-field").
+		WithMessage(`field "name" is deprecated`).
+		WithDetail("field", "name")
+
+	degraded := sos.NewWarning(sos.TEMPORARY).
+		WithSeverity(sos.SeverityDegraded).
+		WithMessage("recommendations unavailable")
+
+	t.Run("header", func(t *testing.T) {
+		h := make(http.Header)
+		sos.WriteWarningHeader(h, deprecated, degraded)
+
+		want := []string{
+			`299 - "deprecated-field: field \"name\" is deprecated"`,
+			`199 - "temporary: recommendations unavailable"`,
+		}
+		if diff := cmp.Diff(h.Values(sos.WarningHeader), want); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("body", func(t *testing.T) {
+		b, err := json.Marshal(map[string]interface{}{"warnings": []*sos.Warning{deprecated}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{`"code":"invalid"`, `"reason":"deprecated-field"`, `"severity":"warning"`} {
+			if !strings.Contains(string(b), want) {
+				t.Errorf("body should contain %s: %s", want, b)
+			}
+		}
+	})
+
+	t.Run("trailer", func(t *testing.T) {
+		md, err := sos.WarningTrailer(deprecated, degraded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ws, err := sos.ParseWarningTrailer(md)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ws) != 2 {
+			t.Fatalf("warnings: got %d, want 2", len(ws))
+		}
+		if ws[0].Reason() != "deprecated-field" || ws[0].Details()["field"] != "name" {
+			t.Errorf("first warning: got %s %v", ws[0].Reason(), ws[0].Details())
+		}
+		if ws[1].Severity() != sos.SeverityDegraded {
+			t.Errorf("severity: got %s, want %s", ws[1].Severity(), sos.SeverityDegraded)
+		}
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		var w sos.Warning
+		w.WithDetail("field", "name").WithDetails(map[string]string{"hint": "use title"})
+		if diff := cmp.Diff(w.Details(), map[string]string{"field": "name", "hint": "use title"}); diff != "" {
+			t.Error(diff)
+		}
+	})
+}